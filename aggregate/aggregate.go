@@ -0,0 +1,141 @@
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/trace"
+	"github.com/pkg/errors"
+	"github.com/stoewer/go-strcase"
+)
+
+type Reducer[T any] func(ctx context.Context, state T, event message.Event) (T, error)
+
+type Aggregate[T any] interface {
+	ID() string
+	Type() string
+	Version() uint64
+	State() T
+	CreatedAt() time.Time
+	CreatedBy() string
+	Changes() []message.Event
+	Apply(ctx context.Context, eventType string, eventVersion uint64, eventData interface{}) error
+}
+
+type aggregateImpl[T any] struct {
+	AggregateID        string
+	AggregateType      string
+	AggregateVersion   uint64
+	AggregateState     T
+	AggregateCreatedAt time.Time
+	AggregateCreatedBy string
+	LoadedVersion      uint64
+	PendingChanges     []message.Event
+	reducers           map[string]Reducer[T]
+}
+
+func (a *aggregateImpl[T]) ID() string {
+	return a.AggregateID
+}
+
+func (a *aggregateImpl[T]) Type() string {
+	return a.AggregateType
+}
+
+func (a *aggregateImpl[T]) Version() uint64 {
+	return a.AggregateVersion
+}
+
+func (a *aggregateImpl[T]) State() T {
+	return a.AggregateState
+}
+
+func (a *aggregateImpl[T]) CreatedAt() time.Time {
+	return a.AggregateCreatedAt
+}
+
+func (a *aggregateImpl[T]) CreatedBy() string {
+	return a.AggregateCreatedBy
+}
+
+func (a *aggregateImpl[T]) Changes() []message.Event {
+	return a.PendingChanges
+}
+
+func (a *aggregateImpl[T]) Apply(ctx context.Context, eventType string, eventVersion uint64, eventData interface{}) error {
+
+	if eventVersion == 0 {
+		eventVersion = 1
+	}
+	event, err := a.newEvent(ctx, eventType, eventVersion, eventData)
+	if err != nil {
+		return errors.Wrap(err, "cannot generate aggregate event")
+	}
+	if err = a.reduce(ctx, event); err != nil {
+		return err
+	}
+	a.PendingChanges = append(a.PendingChanges, event)
+	return nil
+}
+
+func (a *aggregateImpl[T]) reduce(ctx context.Context, event message.Event) error {
+
+	key := strings.TrimPrefix(event.Type(), fmt.Sprintf("%s.", strcase.KebabCase(a.AggregateType)))
+	reducer, ok := a.reducers[key]
+	if !ok {
+		return errors.Errorf("reducer not found for event type `%s`", event.Type())
+	}
+
+	state, err := reducer(ctx, a.AggregateState, event)
+	if err != nil {
+		return errors.Wrapf(err, "cannot reduce event `%s`", event.Type())
+	}
+
+	a.AggregateState = state
+	a.AggregateVersion++
+	if a.AggregateVersion == 1 {
+		a.AggregateCreatedAt = event.Time()
+		a.AggregateCreatedBy = event.Author()
+	}
+	return nil
+}
+
+func (a *aggregateImpl[T]) newEvent(ctx context.Context, eventType string, eventVersion uint64, eventData interface{}) (message.Event, error) {
+
+	cvx := cvxcontext.GetExecutionContenxt(ctx)
+	sc := trace.SpanContextFromContext(ctx)
+	typename := strcase.KebabCase(a.AggregateType)
+
+	eventMap := make(map[string]interface{})
+	eventMap["source"] = fmt.Sprintf("/%s/%s", typename, a.AggregateID)
+	eventMap["id"] = fmt.Sprintf("%020d", a.AggregateVersion+1)
+	eventMap["kind"] = "event"
+	eventMap["type"] = fmt.Sprintf("%s.%s.v%d", typename, eventType, eventVersion)
+	eventMap["time"] = time.Now()
+	eventMap["contentType"] = "application/json"
+	eventMap["encodingType"] = "identity"
+	eventMap["data"] = eventData
+	eventMap["author"] = cvx.Author
+	eventMap["trigger"] = cvx.Trigger
+	eventMap["transaction"] = cvx.Transaction
+	if sc.IsValid() {
+		eventMap["traceparent"] = sc.TraceParent()
+		if sc.TraceState != "" {
+			eventMap["tracestate"] = sc.TraceState
+		}
+	}
+	if eventData == nil {
+		eventMap["data"] = map[string]interface{}{}
+	}
+
+	eventJson, err := json.Marshal(eventMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal event map")
+	}
+	return message.FromJson(eventJson)
+}
@@ -0,0 +1,204 @@
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/jsii-runtime-go"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/cevixe/sdk/trace"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/stoewer/go-strcase"
+)
+
+type RepositoryProps struct {
+	Domain        string `field:"required"`
+	Typename      string `field:"optional"`
+	SnapshotEvery uint64 `field:"optional"`
+}
+
+type Repository[T any] interface {
+	On(eventType string, eventVersion uint64, reducer Reducer[T]) Repository[T]
+	New(ctx context.Context) Aggregate[T]
+	Load(ctx context.Context, id string) (Aggregate[T], error)
+	Save(ctx context.Context, aggregate Aggregate[T], res result.Result) (result.Result, error)
+}
+
+func NewRepository[T any](props *RepositoryProps) Repository[T] {
+	typename := props.Typename
+	if typename == "" {
+		typename = entity.Typename[T]()
+	}
+	return &repositoryImpl[T]{
+		Domain:        props.Domain,
+		Typename:      typename,
+		SnapshotEvery: props.SnapshotEvery,
+		reducers:      make(map[string]Reducer[T]),
+	}
+}
+
+type repositoryImpl[T any] struct {
+	Domain        string
+	Typename      string
+	SnapshotEvery uint64
+	reducers      map[string]Reducer[T]
+}
+
+func (r *repositoryImpl[T]) On(eventType string, eventVersion uint64, reducer Reducer[T]) Repository[T] {
+	if eventVersion == 0 {
+		eventVersion = 1
+	}
+	r.reducers[fmt.Sprintf("%s.v%d", eventType, eventVersion)] = reducer
+	return r
+}
+
+func (r *repositoryImpl[T]) New(ctx context.Context) Aggregate[T] {
+	return r.newAggregate(ulid.Make().String())
+}
+
+func (r *repositoryImpl[T]) Load(ctx context.Context, id string) (Aggregate[T], error) {
+
+	aggregate := r.newAggregate(id)
+	if r.SnapshotEvery > 0 {
+		if err := r.loadSnapshot(ctx, aggregate); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.loadEvents(ctx, aggregate); err != nil {
+		return nil, err
+	}
+	if aggregate.AggregateVersion == 0 {
+		return nil, nil
+	}
+
+	aggregate.LoadedVersion = aggregate.AggregateVersion
+	return aggregate, nil
+}
+
+func (r *repositoryImpl[T]) Save(ctx context.Context, aggregate Aggregate[T], res result.Result) (result.Result, error) {
+
+	if res == nil {
+		res = result.NewResult()
+	}
+	changes := aggregate.Changes()
+	if len(changes) == 0 {
+		return res, nil
+	}
+	aggregateRes, ok := res.(result.AggregateResult)
+	if !ok {
+		return nil, errors.Errorf("result type %T cannot carry aggregate events", res)
+	}
+	aggregateRes.AddEvents(changes...)
+
+	loadedVersion := aggregate.Version() - uint64(len(changes))
+	if r.SnapshotEvery == 0 ||
+		aggregate.Version()/r.SnapshotEvery == loadedVersion/r.SnapshotEvery {
+		return res, nil
+	}
+
+	snapshot, err := entity.NewSnapshot(&entity.SnapshotProps{
+		ID:        aggregate.ID(),
+		Typename:  aggregate.Type(),
+		Version:   aggregate.Version(),
+		State:     aggregate.State(),
+		CreatedAt: aggregate.CreatedAt(),
+		CreatedBy: aggregate.CreatedBy(),
+		LastEvent: changes[len(changes)-1],
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot generate aggregate snapshot")
+	}
+	return aggregateRes.AddSnapshots(snapshot), nil
+}
+
+func (r *repositoryImpl[T]) newAggregate(id string) *aggregateImpl[T] {
+	return &aggregateImpl[T]{
+		AggregateID:    id,
+		AggregateType:  r.Typename,
+		PendingChanges: make([]message.Event, 0),
+		reducers:       r.reducers,
+	}
+}
+
+func (r *repositoryImpl[T]) loadSnapshot(ctx context.Context, aggregate *aggregateImpl[T]) error {
+
+	snapshot, err := entity.FindSnapshot(ctx, &entity.FindOneProps{
+		Domain:   r.Domain,
+		Typename: r.Typename,
+		ID:       aggregate.AggregateID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot load aggregate snapshot")
+	}
+	if snapshot == nil {
+		return nil
+	}
+
+	if err = snapshot.Data(&aggregate.AggregateState); err != nil {
+		return errors.Wrap(err, "cannot read aggregate snapshot state")
+	}
+	aggregate.AggregateVersion = snapshot.Version()
+	aggregate.AggregateCreatedAt = snapshot.CreatedAt()
+	aggregate.AggregateCreatedBy = snapshot.CreatedBy()
+	return nil
+}
+
+func (r *repositoryImpl[T]) loadEvents(ctx context.Context, aggregate *aggregateImpl[T]) error {
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+	table := fmt.Sprintf("dyn-%s-core-eventstore", cvxini.AppName)
+	source := fmt.Sprintf("/%s/%s", strcase.KebabCase(r.Typename), aggregate.AggregateID)
+
+	input := &dynamodb.QueryInput{
+		TableName:              jsii.String(table),
+		KeyConditionExpression: jsii.String("#source = :source AND #id > :id"),
+		ExpressionAttributeNames: map[string]string{
+			"#source": "source",
+			"#id":     "id",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":source": &types.AttributeValueMemberS{Value: source},
+			":id":     &types.AttributeValueMemberS{Value: fmt.Sprintf("%020d", aggregate.AggregateVersion)},
+		},
+		ConsistentRead:   jsii.Bool(true),
+		ScanIndexForward: jsii.Bool(true),
+	}
+
+	for {
+		spanContext, span := trace.Start(ctx, "dynamodb.Query", "table", table)
+		output, err := cvxini.DynamodbClient.Query(spanContext, input)
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			return errors.Wrap(err, "cannot query dynamodb aggregate events")
+		}
+
+		for _, item := range output.Items {
+			event, err := message.FromDynamodb_TableMap(item)
+			if err != nil {
+				return errors.Wrap(err, "cannot read dynamodb event map")
+			}
+			version, err := strconv.ParseUint(event.ID(), 10, 64)
+			if err != nil || version != aggregate.AggregateVersion+1 {
+				return errors.Errorf("unexpected aggregate event `%s/%s` after version %d",
+					event.Source(), event.ID(), aggregate.AggregateVersion)
+			}
+			if err = aggregate.reduce(ctx, event); err != nil {
+				return err
+			}
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,108 @@
+package aggregate_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cevixe/sdk/aggregate"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+)
+
+type Account struct {
+	Balance int `json:"balance"`
+}
+
+type deposit struct {
+	Amount int `json:"amount"`
+}
+
+func newAccountRepository() aggregate.Repository[Account] {
+	return aggregate.NewRepository[Account](&aggregate.RepositoryProps{Domain: "cvxtest", SnapshotEvery: 2}).
+		On("deposited", 1, func(ctx context.Context, state Account, event message.Event) (Account, error) {
+			data := &deposit{}
+			if err := event.Data(data); err != nil {
+				return state, err
+			}
+			state.Balance += data.Amount
+			return state, nil
+		})
+}
+
+func TestRepository_Save(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	repository := newAccountRepository()
+	var id string
+
+	deposits := func(amounts ...int) func(ctx context.Context, msg message.Message) (result.Result, error) {
+		return func(ctx context.Context, msg message.Message) (result.Result, error) {
+			account := repository.New(ctx)
+			if id != "" {
+				loaded, err := repository.Load(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+				account = loaded
+			}
+			id = account.ID()
+			for _, amount := range amounts {
+				if err := account.Apply(ctx, "deposited", 1, &deposit{Amount: amount}); err != nil {
+					return nil, err
+				}
+			}
+			return repository.Save(ctx, account, nil)
+		}
+	}
+
+	harness.Invoke(deposits(10), cvxtest.NewCommand(&cvxtest.MessageProps{Type: "deposit.v1"})).AssertNoError(t)
+	snapshot, err := entity.FindSnapshot(harness.Context(), &entity.FindOneProps{Domain: "cvxtest", Typename: "Account", ID: id})
+	if err != nil || snapshot != nil {
+		t.Fatalf("expected no snapshot before version 2, found %v and %v", snapshot, err)
+	}
+
+	harness.Invoke(deposits(5, 7), cvxtest.NewCommand(&cvxtest.MessageProps{Type: "deposit.v1"})).AssertNoError(t)
+	snapshot, err = entity.FindSnapshot(harness.Context(), &entity.FindOneProps{Domain: "cvxtest", Typename: "Account", ID: id})
+	if err != nil || snapshot == nil || snapshot.Version() != 3 {
+		t.Fatalf("expected snapshot at version 3, found %v and %v", snapshot, err)
+	}
+
+	events, err := harness.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, found %d", len(events))
+	}
+	if entities, err := harness.Entities("Account"); err != nil || len(entities) != 0 {
+		t.Fatalf("expected no live entities, found %v and %v", entities, err)
+	}
+
+	loaded, err := repository.Load(harness.ExecutionContext(events[0]), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Version() != 3 || loaded.State().Balance != 22 {
+		t.Fatalf("expected balance 22 at version 3, found %d at %d", loaded.State().Balance, loaded.Version())
+	}
+}
+
+func TestRepository_Save_RequiresAggregateResult(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ctx := harness.ExecutionContext(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "deposit.v1"}))
+	repository := newAccountRepository()
+
+	account := repository.New(ctx)
+	if err := account.Apply(ctx, "deposited", 1, &deposit{Amount: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repository.Save(ctx, account, &cvxtest.ResultStub{}); err == nil {
+		t.Fatalf("expected error saving aggregate events into a custom result")
+	}
+	if res, err := repository.Save(ctx, repository.New(ctx), &cvxtest.ResultStub{}); err != nil || res == nil {
+		t.Fatalf("expected unchanged aggregate to keep the custom result, found %v and %v", res, err)
+	}
+}
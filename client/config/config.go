@@ -7,18 +7,38 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/cevixe/sdk/client/http"
 )
 
+var services = []string{"dynamodb", "sns", "s3"}
+
 func NewConfig(ctx context.Context) aws.Config {
 
 	region := os.Getenv("AWS_REGION")
+	if region == "" && hasEndpointOverrides() {
+		region = "us-east-1"
+	}
+
 	httpClient := http.NewDefaultClient()
-	http.WarmUp(httpClient, region, []string{"dynamodb", "sns", "s3"})
-	cfg, err := config.LoadDefaultConfig(ctx,
+	if os.Getenv("CVX_HTTP_WARMUP") != "false" {
+		http.WarmUpEndpoints(httpClient, getWarmUpEndpoints(region))
+	}
+
+	loadOptions := []func(*config.LoadOptions) error{
 		config.WithDefaultRegion(region),
 		config.WithHTTPClient(httpClient),
-	)
+	}
+	if accessKey := os.Getenv("CVX_ACCESS_KEY_ID"); accessKey != "" {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(
+				accessKey,
+				os.Getenv("CVX_SECRET_ACCESS_KEY"),
+				os.Getenv("CVX_SESSION_TOKEN"),
+			)))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
 
 	if err != nil {
 		log.Fatalf("unable to load SDK config, %v", err)
@@ -26,3 +46,15 @@ func NewConfig(ctx context.Context) aws.Config {
 
 	return cfg
 }
+
+func getWarmUpEndpoints(region string) []string {
+	endpoints := make([]string, 0, len(services))
+	for _, service := range services {
+		if endpoint := GetEndpoint(service); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		} else {
+			endpoints = append(endpoints, http.GetServiceEndpoint(service, region))
+		}
+	}
+	return endpoints
+}
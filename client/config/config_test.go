@@ -0,0 +1,108 @@
+package config_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/cevixe/sdk/client/config"
+)
+
+// endpointServer records the requests of every overridden service
+type endpointServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	requests []string
+}
+
+func newEndpointServer(t *testing.T) *endpointServer {
+	server := &endpointServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		server.requests = append(server.requests, r.Method+" "+r.Header.Get("X-Amz-Target"))
+		server.mutex.Unlock()
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		_, _ = w.Write([]byte(`{"TableNames":[]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("CVX_DYNAMODB_ENDPOINT", server.URL)
+	t.Setenv("CVX_SNS_ENDPOINT", server.URL)
+	t.Setenv("CVX_S3_ENDPOINT", server.URL)
+	t.Setenv("CVX_ACCESS_KEY_ID", "local")
+	t.Setenv("CVX_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("CVX_SESSION_TOKEN", "token")
+	return server
+}
+
+func (s *endpointServer) takeRequests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	requests := s.requests
+	s.requests = nil
+	return requests
+}
+
+func TestNewConfig_EndpointOverrides(t *testing.T) {
+
+	server := newEndpointServer(t)
+	cfg := config.NewConfig(context.Background())
+
+	if requests := server.takeRequests(); len(requests) != 3 {
+		t.Fatalf("expected one warm up request per service, found %q", requests)
+	}
+	if cfg.Region != "us-east-1" {
+		t.Fatalf("expected default region with endpoint overrides, found `%s`", cfg.Region)
+	}
+
+	credentials, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credentials.AccessKeyID != "local" || credentials.SecretAccessKey != "secret" || credentials.SessionToken != "token" {
+		t.Fatalf("expected static credentials, found %+v", credentials)
+	}
+
+	if _, err = config.NewDynamoDBClient(cfg).ListTables(context.Background(), &dynamodb.ListTablesInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if requests := server.takeRequests(); len(requests) != 1 || requests[0] != "POST DynamoDB_20120810.ListTables" {
+		t.Fatalf("expected dynamodb request on the overridden endpoint, found %q", requests)
+	}
+
+	// the response is not a valid sns document, reaching the server is enough
+	_, _ = config.NewSNSClient(cfg).ListTopics(context.Background(), &sns.ListTopicsInput{})
+	if requests := server.takeRequests(); len(requests) == 0 || requests[0] != "POST " {
+		t.Fatalf("expected sns request on the overridden endpoint, found %q", requests)
+	}
+}
+
+func TestNewConfig_WithoutWarmUp(t *testing.T) {
+
+	server := newEndpointServer(t)
+	t.Setenv("CVX_HTTP_WARMUP", "false")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	cfg := config.NewConfig(context.Background())
+
+	if requests := server.takeRequests(); len(requests) != 0 {
+		t.Fatalf("expected no warm up requests, found %q", requests)
+	}
+	if cfg.Region != "eu-west-1" {
+		t.Fatalf("expected region from the environment, found `%s`", cfg.Region)
+	}
+	if endpoint := config.GetEndpoint("dynamodb"); endpoint != server.URL {
+		t.Fatalf("expected dynamodb endpoint `%s`, found `%s`", server.URL, endpoint)
+	}
+	if endpoint := config.GetEndpoint("sqs"); endpoint != "" {
+		t.Fatalf("expected no sqs endpoint, found `%s`", endpoint)
+	}
+}
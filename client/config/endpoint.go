@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+func GetEndpoint(service string) string {
+	return os.Getenv(fmt.Sprintf("CVX_%s_ENDPOINT", strings.ToUpper(service)))
+}
+
+func hasEndpointOverrides() bool {
+	for _, service := range services {
+		if GetEndpoint(service) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func NewDynamoDBClient(cfg aws.Config) *dynamodb.Client {
+	return dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if endpoint := GetEndpoint("dynamodb"); endpoint != "" {
+			o.EndpointResolver = dynamodb.EndpointResolverFromURL(endpoint)
+		}
+	})
+}
+
+func NewS3Client(cfg aws.Config) *s3.Client {
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint := GetEndpoint("s3"); endpoint != "" {
+			o.EndpointResolver = s3.EndpointResolverFromURL(endpoint)
+			o.UsePathStyle = true
+		}
+		if os.Getenv("CVX_S3_PATH_STYLE") == "true" {
+			o.UsePathStyle = true
+		}
+	})
+}
+
+func NewSNSClient(cfg aws.Config) *sns.Client {
+	return sns.NewFromConfig(cfg, func(o *sns.Options) {
+		if endpoint := GetEndpoint("sns"); endpoint != "" {
+			o.EndpointResolver = sns.EndpointResolverFromURL(endpoint)
+		}
+	})
+}
@@ -7,17 +7,28 @@ import (
 )
 
 func WarmUp(client *http.Client, region string, services []string) {
-	wg := &sync.WaitGroup{}
-	wg.Add(len(services))
+	endpoints := make([]string, 0, len(services))
 	for _, item := range services {
-		go warmUpAwsService(client, region, item, wg)
+		endpoints = append(endpoints, GetServiceEndpoint(item, region))
+	}
+	WarmUpEndpoints(client, endpoints)
+}
+
+func WarmUpEndpoints(client *http.Client, endpoints []string) {
+	wg := &sync.WaitGroup{}
+	wg.Add(len(endpoints))
+	for _, item := range endpoints {
+		go warmUpEndpoint(client, item, wg)
 	}
 	wg.Wait()
 }
 
-func warmUpAwsService(client *http.Client, region string, service string, waitGroup *sync.WaitGroup) {
+func GetServiceEndpoint(service string, region string) string {
 	format := "https://%s.%s.amazonaws.com"
-	url := fmt.Sprintf(format, service, region)
+	return fmt.Sprintf(format, service, region)
+}
+
+func warmUpEndpoint(client *http.Client, url string, waitGroup *sync.WaitGroup) {
 	_, _ = client.Head(url)
 	waitGroup.Done()
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/cevixe/sdk/client/config"
+	"github.com/cevixe/sdk/provision"
+)
+
+func main() {
+
+	app := flag.String("app", os.Getenv("CVX_APP_NAME"), "application name")
+	domains := flag.String("domains", os.Getenv("CVX_DOMAIN_NAME"), "comma separated domain names")
+	indexes := flag.String("indexes", "", "comma separated statestore index names")
+	validate := flag.Bool("validate", false, "validate tables without creating or updating them")
+	flag.Parse()
+
+	if *app == "" || *domains == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	definitions := make([]*provision.TableDefinition, 0)
+	for _, domain := range splitList(*domains) {
+		for _, definition := range provision.Tables(&provision.TablesProps{
+			AppName:    *app,
+			DomainName: domain,
+			Indexes:    splitList(*indexes),
+		}) {
+			if !containsTable(definitions, definition.Name) {
+				definitions = append(definitions, definition)
+			}
+		}
+	}
+
+	ctx := context.Background()
+	client := config.NewDynamoDBClient(config.NewConfig(ctx))
+	for _, definition := range definitions {
+		var err error
+		if *validate {
+			err = provision.Validate(ctx, client, definition)
+		} else {
+			err = provision.Ensure(ctx, client, definition)
+		}
+		if err != nil {
+			log.Fatalf("%s: %v", definition.Name, err)
+		}
+		fmt.Printf("%s: ok\n", definition.Name)
+	}
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func containsTable(definitions []*provision.TableDefinition, name string) bool {
+	for _, definition := range definitions {
+		if definition.Name == name {
+			return true
+		}
+	}
+	return false
+}
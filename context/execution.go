@@ -1,11 +1,16 @@
 package context
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type ExecutionContext struct {
-	Author      string
-	Trigger     string
-	Transaction string
+	Author           string
+	Trigger          string
+	Transaction      string
+	MessageType      string
+	DeduplicationTTL time.Duration
 }
 
 func GetExecutionContenxt(ctx context.Context) *ExecutionContext {
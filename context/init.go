@@ -3,18 +3,55 @@ package context
 import (
 	"context"
 
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/cevixe/sdk/logger"
 )
 
 type InitContext struct {
 	AppName        string
 	DomainName     string
 	HandlerName    string
-	S3Client       *s3.Client
-	SNSClient      *sns.Client
-	DynamodbClient *dynamodb.Client
+	S3Client       S3API
+	SNSClient      SNSAPI
+	DynamodbClient DynamoDBAPI
+	Logger         logger.Logger
+
+	PageTokenSigningKey    string
+	PageTokenEncryptionKey string
+}
+
+type InitContextProps struct {
+	AppName        string        `field:"required"`
+	DomainName     string        `field:"required"`
+	HandlerName    string        `field:"optional"`
+	S3Client       S3API         `field:"optional"`
+	SNSClient      SNSAPI        `field:"optional"`
+	DynamodbClient DynamoDBAPI   `field:"required"`
+	Logger         logger.Logger `field:"optional"`
+
+	PageTokenSigningKey    string `field:"optional"`
+	PageTokenEncryptionKey string `field:"optional"`
+}
+
+func NewInitContext(ctx context.Context, props *InitContextProps) context.Context {
+
+	log := props.Logger
+	if log == nil {
+		log = logger.Default()
+	}
+
+	return context.WithValue(ctx, CevixeInitContextKey,
+		&InitContext{
+			AppName:        props.AppName,
+			DomainName:     props.DomainName,
+			HandlerName:    props.HandlerName,
+			S3Client:       props.S3Client,
+			SNSClient:      props.SNSClient,
+			DynamodbClient: props.DynamodbClient,
+			Logger:         log,
+
+			PageTokenSigningKey:    props.PageTokenSigningKey,
+			PageTokenEncryptionKey: props.PageTokenEncryptionKey,
+		})
 }
 
 func GetInitContenxt(ctx context.Context) *InitContext {
@@ -0,0 +1,71 @@
+package context_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/logger"
+)
+
+// dynamodbStub implements only the narrow DynamoDBAPI
+type dynamodbStub struct {
+	cvxcontext.DynamoDBAPI
+}
+
+func (s *dynamodbStub) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{}, nil
+}
+
+type snsStub struct {
+	cvxcontext.SNSAPI
+}
+
+func (s *snsStub) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	return &sns.PublishOutput{}, nil
+}
+
+func TestNewInitContext(t *testing.T) {
+
+	dynamodbClient := &dynamodbStub{}
+	snsClient := &snsStub{}
+	ctx := cvxcontext.NewInitContext(context.Background(), &cvxcontext.InitContextProps{
+		AppName:                "shop",
+		DomainName:             "sales",
+		HandlerName:            "orders",
+		SNSClient:              snsClient,
+		DynamodbClient:         dynamodbClient,
+		PageTokenSigningKey:    "signing",
+		PageTokenEncryptionKey: "encryption",
+	})
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+	if cvxini.AppName != "shop" || cvxini.DomainName != "sales" || cvxini.HandlerName != "orders" {
+		t.Fatalf("unexpected names %s/%s/%s", cvxini.AppName, cvxini.DomainName, cvxini.HandlerName)
+	}
+	if cvxini.DynamodbClient != dynamodbClient || cvxini.SNSClient != snsClient || cvxini.S3Client != nil {
+		t.Fatalf("unexpected clients %+v", cvxini)
+	}
+	if cvxini.PageTokenSigningKey != "signing" || cvxini.PageTokenEncryptionKey != "encryption" {
+		t.Fatalf("unexpected page token keys `%s` and `%s`", cvxini.PageTokenSigningKey, cvxini.PageTokenEncryptionKey)
+	}
+	if cvxini.Logger != logger.Default() {
+		t.Fatal("expected default logger")
+	}
+	if _, err := cvxini.DynamodbClient.GetItem(ctx, &dynamodb.GetItemInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	custom := logger.NewJSONLogger(nil, logger.Level_Error)
+	ctx = cvxcontext.NewInitContext(context.Background(), &cvxcontext.InitContextProps{
+		AppName:        "shop",
+		DomainName:     "sales",
+		DynamodbClient: dynamodbClient,
+		Logger:         custom,
+	})
+	if cvxcontext.GetInitContenxt(ctx).Logger != custom {
+		t.Fatal("expected custom logger")
+	}
+}
@@ -0,0 +1,46 @@
+package context
+
+import (
+	"context"
+
+	"github.com/cevixe/sdk/logger"
+	"github.com/cevixe/sdk/trace"
+)
+
+func GetLogger(ctx context.Context) logger.Logger {
+
+	log := logger.Default()
+	fields := make([]interface{}, 0)
+
+	if cvxini, ok := ctx.Value(CevixeInitContextKey).(*InitContext); ok && cvxini != nil {
+		if cvxini.Logger != nil {
+			log = cvxini.Logger
+		}
+		fields = append(fields,
+			"app", cvxini.AppName,
+			"domain", cvxini.DomainName,
+			"handler", cvxini.HandlerName,
+		)
+	}
+
+	if cvxexe, ok := ctx.Value(CevixeExecutionContextKey).(*ExecutionContext); ok && cvxexe != nil {
+		fields = append(fields,
+			"transaction", cvxexe.Transaction,
+			"trigger", cvxexe.Trigger,
+			"author", cvxexe.Author,
+			"messageType", cvxexe.MessageType,
+		)
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			"traceId", sc.TraceID.String(),
+			"spanId", sc.SpanID.String(),
+		)
+	}
+
+	if len(fields) == 0 {
+		return log
+	}
+	return log.With(fields...)
+}
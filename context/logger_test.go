@@ -0,0 +1,84 @@
+package context_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/logger"
+	"github.com/cevixe/sdk/trace"
+)
+
+func logEntry(t *testing.T, log logger.Logger, output *bytes.Buffer) map[string]interface{} {
+	output.Reset()
+	log.Info("processed")
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	delete(entry, "time")
+	delete(entry, "level")
+	delete(entry, "msg")
+	return entry
+}
+
+func TestGetLogger(t *testing.T) {
+
+	output := &bytes.Buffer{}
+	base := logger.NewJSONLogger(output, logger.Level_Info)
+	previous := logger.Default()
+	logger.SetDefault(base)
+	t.Cleanup(func() { logger.SetDefault(previous) })
+
+	if entry := logEntry(t, cvxcontext.GetLogger(context.Background()), output); len(entry) != 0 {
+		t.Fatalf("expected no enrichment without cevixe context, found %v", entry)
+	}
+
+	ctx := cvxcontext.NewInitContext(context.Background(), &cvxcontext.InitContextProps{
+		AppName:        "shop",
+		DomainName:     "sales",
+		HandlerName:    "orders",
+		DynamodbClient: &dynamodbStub{},
+		Logger:         base.With("component", "test"),
+	})
+	expected := map[string]interface{}{
+		"component": "test",
+		"app":       "shop",
+		"domain":    "sales",
+		"handler":   "orders",
+	}
+	assertEntry(t, expected, logEntry(t, cvxcontext.GetLogger(ctx), output))
+
+	ctx = context.WithValue(ctx, cvxcontext.CevixeExecutionContextKey, &cvxcontext.ExecutionContext{
+		Author:      "alice",
+		Trigger:     "msg-1",
+		Transaction: "txn-1",
+		MessageType: "place-order.v1",
+	})
+	sc, err := trace.ParseTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx = trace.ContextWithRemoteSpanContext(ctx, sc)
+	expected["author"] = "alice"
+	expected["trigger"] = "msg-1"
+	expected["transaction"] = "txn-1"
+	expected["messageType"] = "place-order.v1"
+	expected["traceId"] = "0af7651916cd43dd8448eb211c80319c"
+	expected["spanId"] = "b7ad6b7169203331"
+	assertEntry(t, expected, logEntry(t, cvxcontext.GetLogger(ctx), output))
+}
+
+func assertEntry(t *testing.T, expected map[string]interface{}, entry map[string]interface{}) {
+	t.Helper()
+	if len(entry) != len(expected) {
+		t.Fatalf("expected fields %v, found %v", expected, entry)
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Fatalf("expected %s=%v, found %v", key, value, entry[key])
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package cvxtest
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+)
+
+func (i *Invocation) AssertNoError(t testing.TB) {
+	t.Helper()
+	if i.Err != nil {
+		t.Fatalf("unexpected handler error: %v", i.Err)
+	}
+}
+
+func (i *Invocation) AssertError(t testing.TB) error {
+	t.Helper()
+	if i.Err == nil {
+		t.Fatalf("expected handler error, found none")
+	}
+	return i.Err
+}
+
+func (h *Harness) AssertEntity(t testing.TB, typename string, id string, expected interface{}) entity.Entity {
+	t.Helper()
+	value, err := h.FindEntity(typename, id)
+	if err != nil {
+		t.Fatalf("cannot find entity %s/%s: %v", typename, id, err)
+	}
+	if value == nil {
+		t.Fatalf("entity %s/%s not found", typename, id)
+	}
+	actual := make(map[string]interface{})
+	if err = value.Data(&actual); err != nil {
+		t.Fatalf("cannot read entity %s/%s data: %v", typename, id, err)
+	}
+	if diff := DiffJSON(expected, actual); diff != "" {
+		t.Fatalf("entity %s/%s data mismatch (-expected +actual):\n%s", typename, id, diff)
+	}
+	return value
+}
+
+func (h *Harness) AssertCommand(t testing.TB, messageType string) message.Command {
+	t.Helper()
+	commands, err := h.Commands()
+	if err != nil {
+		t.Fatalf("cannot read committed commands: %v", err)
+	}
+	for _, command := range commands {
+		if command.Type() == messageType {
+			return command
+		}
+	}
+	found := make([]string, 0, len(commands))
+	for _, command := range commands {
+		found = append(found, command.Type())
+	}
+	t.Fatalf("command `%s` not committed, found %v", messageType, found)
+	return nil
+}
+
+func (h *Harness) AssertCommandCount(t testing.TB, expected int) {
+	t.Helper()
+	commands, err := h.Commands()
+	if err != nil {
+		t.Fatalf("cannot read committed commands: %v", err)
+	}
+	if len(commands) != expected {
+		t.Fatalf("expected %d committed commands, found %d", expected, len(commands))
+	}
+}
+
+func normalizeJSON(value interface{}) (interface{}, error) {
+	buffer, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	if err = json.Unmarshal(buffer, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func equalJSON(expected interface{}, actual interface{}) bool {
+	left, err := normalizeJSON(expected)
+	if err != nil {
+		return false
+	}
+	right, err := normalizeJSON(actual)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
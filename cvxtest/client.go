@@ -0,0 +1,61 @@
+package cvxtest
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// ClientStub serves every call from the store, each On hook replaces a
+// single operation so tests can count, throttle or hold requests
+type ClientStub struct {
+	*Store
+	OnGetItem            func(ctx context.Context, params *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	OnQuery              func(ctx context.Context, params *dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	OnBatchGetItem       func(ctx context.Context, params *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error)
+	OnTransactWriteItems func(ctx context.Context, params *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+func (c *ClientStub) GetItem(
+	ctx context.Context,
+	params *dynamodb.GetItemInput,
+	optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	if c.OnGetItem != nil {
+		return c.OnGetItem(ctx, params)
+	}
+	return c.Store.GetItem(ctx, params, optFns...)
+}
+
+func (c *ClientStub) Query(
+	ctx context.Context,
+	params *dynamodb.QueryInput,
+	optFns ...func(*dynamodb.Options),
+) (*dynamodb.QueryOutput, error) {
+	if c.OnQuery != nil {
+		return c.OnQuery(ctx, params)
+	}
+	return c.Store.Query(ctx, params, optFns...)
+}
+
+func (c *ClientStub) BatchGetItem(
+	ctx context.Context,
+	params *dynamodb.BatchGetItemInput,
+	optFns ...func(*dynamodb.Options),
+) (*dynamodb.BatchGetItemOutput, error) {
+	if c.OnBatchGetItem != nil {
+		return c.OnBatchGetItem(ctx, params)
+	}
+	return c.Store.BatchGetItem(ctx, params, optFns...)
+}
+
+func (c *ClientStub) TransactWriteItems(
+	ctx context.Context,
+	params *dynamodb.TransactWriteItemsInput,
+	optFns ...func(*dynamodb.Options),
+) (*dynamodb.TransactWriteItemsOutput, error) {
+	if c.OnTransactWriteItems != nil {
+		return c.OnTransactWriteItems(ctx, params)
+	}
+	return c.Store.TransactWriteItems(ctx, params, optFns...)
+}
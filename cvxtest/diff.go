@@ -0,0 +1,78 @@
+package cvxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func DiffJSON(expected interface{}, actual interface{}) string {
+
+	if equalJSON(expected, actual) {
+		return ""
+	}
+
+	left, err := normalizeJSON(expected)
+	if err != nil {
+		return fmt.Sprintf("cannot normalize expected value: %v", err)
+	}
+	right, err := normalizeJSON(actual)
+	if err != nil {
+		return fmt.Sprintf("cannot normalize actual value: %v", err)
+	}
+
+	leftLines := strings.Split(indentJSON(left), "\n")
+	rightLines := strings.Split(indentJSON(right), "\n")
+	return diffLines(leftLines, rightLines)
+}
+
+func indentJSON(value interface{}) string {
+	buffer, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(buffer)
+}
+
+func diffLines(left []string, right []string) string {
+
+	lcs := make([][]int, len(left)+1)
+	for idx := range lcs {
+		lcs[idx] = make([]int, len(right)+1)
+	}
+	for i := len(left) - 1; i >= 0; i-- {
+		for j := len(right) - 1; j >= 0; j-- {
+			if left[i] == right[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	builder := &strings.Builder{}
+	i, j := 0, 0
+	for i < len(left) && j < len(right) {
+		switch {
+		case left[i] == right[j]:
+			fmt.Fprintf(builder, "  %s\n", left[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			fmt.Fprintf(builder, "- %s\n", left[i])
+			i++
+		default:
+			fmt.Fprintf(builder, "+ %s\n", right[j])
+			j++
+		}
+	}
+	for ; i < len(left); i++ {
+		fmt.Fprintf(builder, "- %s\n", left[i])
+	}
+	for ; j < len(right); j++ {
+		fmt.Fprintf(builder, "+ %s\n", right[j])
+	}
+	return builder.String()
+}
@@ -0,0 +1,77 @@
+package cvxtest
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cevixe/sdk/message"
+	"github.com/oklog/ulid/v2"
+)
+
+type MessageProps struct {
+	Source      string      `field:"optional"`
+	ID          string      `field:"optional"`
+	Type        string      `field:"required"`
+	Time        time.Time   `field:"optional"`
+	Data        interface{} `field:"optional"`
+	Author      string      `field:"optional"`
+	Trigger     string      `field:"optional"`
+	Transaction string      `field:"optional"`
+	TraceParent string      `field:"optional"`
+}
+
+func NewCommand(props *MessageProps) message.Command {
+	return NewMessage(message.MessageKind_Command, props)
+}
+
+func NewEvent(props *MessageProps) message.Event {
+	return NewMessage(message.MessageKind_Event, props)
+}
+
+func NewMessage(kind message.MessageKind, props *MessageProps) message.Message {
+
+	messageMap := map[string]interface{}{
+		"source":       props.Source,
+		"id":           props.ID,
+		"kind":         kind,
+		"type":         props.Type,
+		"time":         props.Time,
+		"contentType":  "application/json",
+		"encodingType": "identity",
+		"data":         props.Data,
+		"author":       props.Author,
+		"trigger":      props.Trigger,
+		"transaction":  props.Transaction,
+	}
+	if props.Source == "" {
+		messageMap["source"] = "/cvxtest"
+	}
+	if props.ID == "" {
+		messageMap["id"] = ulid.Make().String()
+	}
+	if props.Time.IsZero() {
+		messageMap["time"] = time.Now().UTC()
+	}
+	if props.Data == nil {
+		messageMap["data"] = map[string]interface{}{}
+	}
+	if props.Author == "" {
+		messageMap["author"] = "cvxtest"
+	}
+	if props.Transaction == "" {
+		messageMap["transaction"] = ulid.Make().String()
+	}
+	if props.TraceParent != "" {
+		messageMap["traceparent"] = props.TraceParent
+	}
+
+	buffer, err := json.Marshal(messageMap)
+	if err != nil {
+		panic(err)
+	}
+	msg, err := message.FromJson(buffer)
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
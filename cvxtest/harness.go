@@ -0,0 +1,210 @@
+package cvxtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/handler"
+	"github.com/cevixe/sdk/logger"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/cevixe/sdk/runtime"
+	"github.com/pkg/errors"
+)
+
+type HarnessProps struct {
+	AppName     string        `field:"optional"`
+	DomainName  string        `field:"optional"`
+	HandlerName string        `field:"optional"`
+	Store       *Store        `field:"optional"`
+	Logger      logger.Logger `field:"optional"`
+
+	// DynamodbClient replaces the store as the client of the harness context,
+	// seeding and assertions keep using the store
+	DynamodbClient         cvxcontext.DynamoDBAPI `field:"optional"`
+	PageTokenSigningKey    string                 `field:"optional"`
+	PageTokenEncryptionKey string                 `field:"optional"`
+}
+
+type Harness struct {
+	Store *Store
+	ctx   context.Context
+}
+
+type Invocation struct {
+	Result result.Result
+	Err    error
+}
+
+func NewHarness(props *HarnessProps) *Harness {
+
+	if props == nil {
+		props = &HarnessProps{}
+	}
+	store := props.Store
+	if store == nil {
+		store = NewStore()
+	}
+	log := props.Logger
+	if log == nil {
+		log = logger.NewJSONLogger(io.Discard, logger.Level_Error)
+	}
+	var client cvxcontext.DynamoDBAPI = store
+	if props.DynamodbClient != nil {
+		client = props.DynamodbClient
+	}
+
+	ctx := cvxcontext.NewInitContext(context.Background(),
+		&cvxcontext.InitContextProps{
+			AppName:        defaultString(props.AppName, "cvxtest"),
+			DomainName:     defaultString(props.DomainName, "cvxtest"),
+			HandlerName:    defaultString(props.HandlerName, "cvxtest"),
+			DynamodbClient: client,
+			Logger:         log,
+
+			PageTokenSigningKey:    props.PageTokenSigningKey,
+			PageTokenEncryptionKey: props.PageTokenEncryptionKey,
+		})
+
+	return &Harness{
+		Store: store,
+		ctx:   ctx,
+	}
+}
+
+func (h *Harness) Context() context.Context {
+	return h.ctx
+}
+
+func (h *Harness) ExecutionContext(msg message.Message) context.Context {
+	return context.WithValue(h.ctx, cvxcontext.CevixeExecutionContextKey,
+		&cvxcontext.ExecutionContext{
+			Author:      msg.Author(),
+			Trigger:     fmt.Sprintf("%s/%s", msg.Source(), msg.ID()),
+			Transaction: msg.Transaction(),
+			MessageType: msg.Type(),
+		})
+}
+
+func (h *Harness) NewEntity(state interface{}) entity.Entity {
+	ctx := h.ExecutionContext(NewCommand(&MessageProps{Type: "cvxtest.seed.v1"}))
+	return entity.Create(ctx, state).Execute()
+}
+
+func (h *Harness) Seed(items ...interface{}) error {
+	cvxini := cvxcontext.GetInitContenxt(h.ctx)
+	for _, item := range items {
+		switch value := item.(type) {
+		case entity.Entity:
+			dynamoMap, err := entity.ToDynamodb_Map(value)
+			if err != nil {
+				return errors.Wrap(err, "cannot marshal seed entity")
+			}
+			table := fmt.Sprintf("dyn-%s-%s-statestore", cvxini.AppName, cvxini.DomainName)
+			if err = h.Store.Put(table, withoutNulls(dynamoMap)); err != nil {
+				return errors.Wrap(err, "cannot seed entity")
+			}
+		case message.Message:
+			dynamoMap, err := message.ToDynamodb_Map(value)
+			if err != nil {
+				return errors.Wrap(err, "cannot marshal seed message")
+			}
+			table := fmt.Sprintf("dyn-%s-core-%sstore", cvxini.AppName, value.Kind())
+			if err = h.Store.Put(table, withoutNulls(dynamoMap)); err != nil {
+				return errors.Wrap(err, "cannot seed message")
+			}
+		default:
+			return errors.Errorf("unsupported seed item type %T", item)
+		}
+	}
+	return nil
+}
+
+func (h *Harness) Invoke(hdl handler.Handler, msg message.Message, opts ...runtime.Option) *Invocation {
+
+	invocation := &Invocation{}
+	capture := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		res, err := hdl(ctx, msg)
+		invocation.Result = res
+		return res, err
+	}
+
+	opts = append([]runtime.Option{runtime.WithMetrics("")}, opts...)
+	invocation.Err = runtime.Execute(h.ctx, capture, msg, opts...)
+	return invocation
+}
+
+func (h *Harness) FindEntity(typename string, id string) (entity.Entity, error) {
+	cvxini := cvxcontext.GetInitContenxt(h.ctx)
+	return entity.FindOne(h.ctx, &entity.FindOneProps{
+		Domain:   cvxini.DomainName,
+		Typename: typename,
+		ID:       id,
+	})
+}
+
+func (h *Harness) Entities(typename string) ([]entity.Entity, error) {
+	cvxini := cvxcontext.GetInitContenxt(h.ctx)
+	table := fmt.Sprintf("dyn-%s-%s-statestore", cvxini.AppName, cvxini.DomainName)
+	entities := make([]entity.Entity, 0)
+	for _, item := range h.Store.Items(table) {
+		if typeValue, ok := item["__typename"].(*types.AttributeValueMemberS); !ok || typeValue.Value != typename {
+			continue
+		}
+		if _, ok := item["__snapshot"]; ok {
+			continue
+		}
+		value, err := entity.FromDynamodb_TableMap(item)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read committed entity")
+		}
+		entities = append(entities, value)
+	}
+	return entities, nil
+}
+
+func (h *Harness) Commands() ([]message.Command, error) {
+	return h.messages(message.MessageKind_Command)
+}
+
+func (h *Harness) Events() ([]message.Event, error) {
+	return h.messages(message.MessageKind_Event)
+}
+
+func (h *Harness) messages(kind message.MessageKind) ([]message.Message, error) {
+	cvxini := cvxcontext.GetInitContenxt(h.ctx)
+	table := fmt.Sprintf("dyn-%s-core-%sstore", cvxini.AppName, kind)
+	messages := make([]message.Message, 0)
+	for _, item := range h.Store.Items(table) {
+		msg, err := message.FromDynamodb_TableMap(item)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read committed message")
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+func defaultString(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func withoutNulls(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	for key, value := range item {
+		if value == nil {
+			delete(item, key)
+			continue
+		}
+		if _, ok := value.(*types.AttributeValueMemberNULL); ok {
+			delete(item, key)
+		}
+	}
+	return item
+}
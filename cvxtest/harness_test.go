@@ -0,0 +1,118 @@
+package cvxtest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/pkg/errors"
+)
+
+type Order struct {
+	Name    string `json:"name"`
+	Shipped bool   `json:"shipped"`
+}
+
+var errUnknownOrder = errors.New("unknown order")
+
+func placeOrder(ctx context.Context, msg message.Message) (result.Result, error) {
+	order := &Order{}
+	if err := msg.Data(order); err != nil {
+		return nil, err
+	}
+	created := entity.Create(ctx, order).Execute()
+	command := cvxtest.NewCommand(&cvxtest.MessageProps{
+		Type: "ship-order.v1",
+		Data: map[string]string{"id": created.ID()},
+	})
+	return result.NewResult().AddEntities(created).AddCommands(command), nil
+}
+
+func shipOrder(ctx context.Context, msg message.Message) (result.Result, error) {
+	request := make(map[string]string)
+	if err := msg.Data(&request); err != nil {
+		return nil, err
+	}
+	current, err := entity.FindOne(ctx, &entity.FindOneProps{Domain: "cvxtest", Typename: "Order", ID: request["id"]})
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, errUnknownOrder
+	}
+	order := &Order{}
+	if err = current.Data(order); err != nil {
+		return nil, err
+	}
+	order.Shipped = true
+	shipped := current.Mutate(ctx, order).SetEvent("shipped", 2, map[string]string{"id": current.ID()}).Execute()
+	return result.NewResult().AddEntities(shipped), nil
+}
+
+func TestHarness_Invoke(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+
+	placed := harness.Invoke(placeOrder, cvxtest.NewCommand(&cvxtest.MessageProps{
+		Type: "place-order.v1",
+		Data: &Order{Name: "book"},
+	}))
+	placed.AssertNoError(t)
+
+	command := harness.AssertCommand(t, "ship-order.v1")
+	created := placed.Result.GetEntities()[0]
+	harness.AssertEntity(t, "Order", created.ID(), &Order{Name: "book"})
+
+	harness.Invoke(shipOrder, command).AssertNoError(t)
+	shipped := harness.AssertEntity(t, "Order", created.ID(), &Order{Name: "book", Shipped: true})
+	if shipped.Version() != 2 {
+		t.Fatalf("expected version 2, found %d", shipped.Version())
+	}
+	event, err := shipped.LastEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type() != "order.shipped.v2" || event.Trigger() != command.Source()+"/"+command.ID() {
+		t.Fatalf("unexpected last event `%s` triggered by `%s`", event.Type(), event.Trigger())
+	}
+
+	failed := harness.Invoke(shipOrder, cvxtest.NewCommand(&cvxtest.MessageProps{
+		Type: "ship-order.v1",
+		Data: map[string]string{"id": "missing"},
+	}))
+	if err = failed.AssertError(t); !errors.Is(err, errUnknownOrder) {
+		t.Fatalf("expected unknown order error, found %v", err)
+	}
+}
+
+func TestScenario(t *testing.T) {
+
+	scenario := cvxtest.NewScenario(t, shipOrder)
+	order := scenario.Harness().NewEntity(&Order{Name: "book"})
+
+	scenario.
+		Given(order).
+		When(cvxtest.NewCommand(&cvxtest.MessageProps{
+			Type: "ship-order.v1",
+			Data: map[string]string{"id": order.ID()},
+		})).
+		Then().
+		NoError().
+		EntityState("Order", order.ID(), &Order{Name: "book", Shipped: true}).
+		EntityEvent("Order", "", "order.shipped.v2", map[string]string{"id": order.ID()}).
+		EntityVersion("Order", order.ID(), 1).
+		EntityCount(1).
+		NoCommands()
+
+	cvxtest.NewScenario(t, shipOrder).
+		When(cvxtest.NewCommand(&cvxtest.MessageProps{
+			Type: "ship-order.v1",
+			Data: map[string]string{"id": "missing"},
+		})).
+		Then().
+		Error(errUnknownOrder).
+		EntityCount(0)
+}
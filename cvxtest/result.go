@@ -0,0 +1,32 @@
+package cvxtest
+
+import (
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+)
+
+// ResultStub implements only result.Result, as custom results written
+// outside the sdk do, without aggregate events or snapshots
+type ResultStub struct {
+	Entities []entity.Entity
+	Commands []message.Command
+}
+
+func (r *ResultStub) GetEntities() []entity.Entity {
+	return r.Entities
+}
+
+func (r *ResultStub) AddEntities(entities ...entity.Entity) result.Result {
+	r.Entities = append(r.Entities, entities...)
+	return r
+}
+
+func (r *ResultStub) GetCommands() []message.Command {
+	return r.Commands
+}
+
+func (r *ResultStub) AddCommands(commands ...message.Command) result.Result {
+	r.Commands = append(r.Commands, commands...)
+	return r
+}
@@ -0,0 +1,244 @@
+package cvxtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/handler"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/runtime"
+	"github.com/pkg/errors"
+)
+
+type Scenario struct {
+	t        testing.TB
+	harness  *Harness
+	handler  handler.Handler
+	options  []runtime.Option
+	versions map[string]uint64
+}
+
+type Expectation struct {
+	t          testing.TB
+	harness    *Harness
+	invocation *Invocation
+	versions   map[string]uint64
+}
+
+func NewScenario(t testing.TB, hdl handler.Handler, opts ...runtime.Option) *Scenario {
+	return NewScenarioWithHarness(t, NewHarness(nil), hdl, opts...)
+}
+
+func NewScenarioWithHarness(t testing.TB, harness *Harness, hdl handler.Handler, opts ...runtime.Option) *Scenario {
+	return &Scenario{
+		t:        t,
+		harness:  harness,
+		handler:  hdl,
+		options:  opts,
+		versions: make(map[string]uint64),
+	}
+}
+
+func (s *Scenario) Harness() *Harness {
+	return s.harness
+}
+
+func (s *Scenario) Given(items ...interface{}) *Scenario {
+	s.t.Helper()
+	if err := s.harness.Seed(items...); err != nil {
+		s.t.Fatalf("cannot seed scenario: %v", err)
+	}
+	for _, item := range items {
+		if value, ok := item.(entity.Entity); ok {
+			s.versions[entityKey(value.Type(), value.ID())] = value.Version()
+		}
+	}
+	return s
+}
+
+func (s *Scenario) When(msg message.Message) *Expectation {
+	s.t.Helper()
+	return &Expectation{
+		t:          s.t,
+		harness:    s.harness,
+		invocation: s.harness.Invoke(s.handler, msg, s.options...),
+		versions:   s.versions,
+	}
+}
+
+func (e *Expectation) Then() *Expectation {
+	return e
+}
+
+func (e *Expectation) Invocation() *Invocation {
+	return e.invocation
+}
+
+func (e *Expectation) NoError() *Expectation {
+	e.t.Helper()
+	e.invocation.AssertNoError(e.t)
+	return e
+}
+
+func (e *Expectation) Error(target error) *Expectation {
+	e.t.Helper()
+	err := e.invocation.AssertError(e.t)
+	if target != nil && !errors.Is(err, target) {
+		e.t.Fatalf("expected handler error `%v`, found `%v`", target, err)
+	}
+	return e
+}
+
+func (e *Expectation) EntityState(typename string, id string, expected interface{}) *Expectation {
+	e.t.Helper()
+	value := e.committedEntity(typename, id)
+	actual := make(map[string]interface{})
+	if err := value.Data(&actual); err != nil {
+		e.t.Fatalf("cannot read entity %s/%s data: %v", typename, value.ID(), err)
+	}
+	if diff := DiffJSON(expected, actual); diff != "" {
+		e.t.Fatalf("entity %s/%s state mismatch (-expected +actual):\n%s", typename, value.ID(), diff)
+	}
+	return e
+}
+
+func (e *Expectation) EntityEvent(typename string, id string, eventType string, expected interface{}) *Expectation {
+	e.t.Helper()
+	value := e.committedEntity(typename, id)
+	event, err := value.LastEvent()
+	if err != nil {
+		e.t.Fatalf("cannot read entity %s/%s last event: %v", typename, value.ID(), err)
+	}
+	if event.Type() != eventType {
+		e.t.Fatalf("entity %s/%s last event type mismatch: expected `%s`, found `%s`",
+			typename, value.ID(), eventType, event.Type())
+	}
+	if expected == nil {
+		return e
+	}
+	actual := make(map[string]interface{})
+	if err = event.Data(&actual); err != nil {
+		e.t.Fatalf("cannot read entity %s/%s last event data: %v", typename, value.ID(), err)
+	}
+	if diff := DiffJSON(expected, actual); diff != "" {
+		e.t.Fatalf("entity %s/%s last event data mismatch (-expected +actual):\n%s", typename, value.ID(), diff)
+	}
+	return e
+}
+
+func (e *Expectation) EntityVersion(typename string, id string, increment uint64) *Expectation {
+	e.t.Helper()
+	value := e.committedEntity(typename, id)
+	previous := e.versions[entityKey(typename, value.ID())]
+	if value.Version() != previous+increment {
+		e.t.Fatalf("entity %s/%s version mismatch: expected %d (+%d), found %d",
+			typename, value.ID(), previous+increment, increment, value.Version())
+	}
+	return e
+}
+
+func (e *Expectation) EntityDeleted(typename string, id string) *Expectation {
+	e.t.Helper()
+	value := e.committedEntity(typename, id)
+	if value.Status() != entity.EntityStatus_Dead {
+		e.t.Fatalf("entity %s/%s expected to be deleted, found status `%s`", typename, value.ID(), value.Status())
+	}
+	return e
+}
+
+func (e *Expectation) EntityCount(expected int) *Expectation {
+	e.t.Helper()
+	found := 0
+	if e.invocation.Result != nil {
+		found = len(e.invocation.Result.GetEntities())
+	}
+	if found != expected {
+		e.t.Fatalf("expected %d entities in result, found %d", expected, found)
+	}
+	return e
+}
+
+func (e *Expectation) Command(messageType string, expected interface{}) *Expectation {
+	e.t.Helper()
+	candidates := make([]string, 0)
+	var mismatch string
+	for _, command := range e.resultCommands() {
+		if command.Type() != messageType {
+			candidates = append(candidates, command.Type())
+			continue
+		}
+		if expected == nil {
+			return e
+		}
+		actual := make(map[string]interface{})
+		if err := command.Data(&actual); err != nil {
+			e.t.Fatalf("cannot read command `%s` data: %v", messageType, err)
+		}
+		diff := DiffJSON(expected, actual)
+		if diff == "" {
+			return e
+		}
+		mismatch = diff
+	}
+	if mismatch != "" {
+		e.t.Fatalf("command `%s` data mismatch (-expected +actual):\n%s", messageType, mismatch)
+	}
+	e.t.Fatalf("command `%s` not found in result, found %v", messageType, candidates)
+	return e
+}
+
+func (e *Expectation) CommandCount(expected int) *Expectation {
+	e.t.Helper()
+	if found := len(e.resultCommands()); found != expected {
+		e.t.Fatalf("expected %d commands in result, found %d", expected, found)
+	}
+	return e
+}
+
+func (e *Expectation) NoCommands() *Expectation {
+	e.t.Helper()
+	return e.CommandCount(0)
+}
+
+func (e *Expectation) resultCommands() []message.Command {
+	if e.invocation.Result == nil {
+		return nil
+	}
+	return e.invocation.Result.GetCommands()
+}
+
+func (e *Expectation) committedEntity(typename string, id string) entity.Entity {
+	e.t.Helper()
+	if id == "" {
+		id = e.resultEntityID(typename)
+	}
+	value, err := e.harness.FindEntity(typename, id)
+	if err != nil {
+		e.t.Fatalf("cannot find entity %s/%s: %v", typename, id, err)
+	}
+	if value == nil {
+		e.t.Fatalf("entity %s/%s not committed", typename, id)
+	}
+	return value
+}
+
+func (e *Expectation) resultEntityID(typename string) string {
+	e.t.Helper()
+	ids := make([]string, 0)
+	if e.invocation.Result != nil {
+		for _, value := range e.invocation.Result.GetEntities() {
+			if value.Type() == typename {
+				ids = append(ids, value.ID())
+			}
+		}
+	}
+	if len(ids) != 1 {
+		e.t.Fatalf("expected exactly one %s entity in result to infer its id, found %d", typename, len(ids))
+	}
+	return ids[0]
+}
+
+func entityKey(typename string, id string) string {
+	return fmt.Sprintf("%s/%s", typename, id)
+}
@@ -0,0 +1,23 @@
+package cvxtest
+
+import (
+	"github.com/cevixe/sdk/internal/memstore"
+)
+
+type Store = memstore.Store
+
+type TableSchema = memstore.TableSchema
+
+type IndexSchema = memstore.IndexSchema
+
+func NewStore() *Store {
+	return memstore.NewStore()
+}
+
+func GetTableSchema(table string) TableSchema {
+	return memstore.GetTableSchema(table)
+}
+
+func GetIndexSchema(index string) IndexSchema {
+	return memstore.GetIndexSchema(index)
+}
@@ -0,0 +1,137 @@
+package entity
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+)
+
+type KeyOperator string
+
+const (
+	KeyOperator_Equal              KeyOperator = "="
+	KeyOperator_LessThan           KeyOperator = "<"
+	KeyOperator_LessThanOrEqual    KeyOperator = "<="
+	KeyOperator_GreaterThan        KeyOperator = ">"
+	KeyOperator_GreaterThanOrEqual KeyOperator = ">="
+	KeyOperator_Between            KeyOperator = "between"
+	KeyOperator_BeginsWith         KeyOperator = "begins_with"
+)
+
+type SortOrder string
+
+const (
+	SortOrder_Descending SortOrder = "desc"
+	SortOrder_Ascending  SortOrder = "asc"
+)
+
+type KeyCondition struct {
+	Operator KeyOperator `field:"required"`
+	Value    string      `field:"required"`
+	Until    string      `field:"optional"`
+}
+
+type queryRange struct {
+	SortKey       *KeyCondition
+	Order         SortOrder
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+func applyQueryRange(input *dynamodb.QueryInput, r *queryRange) error {
+
+	switch r.Order {
+	case "", SortOrder_Descending:
+		input.ScanIndexForward = jsii.Bool(false)
+	case SortOrder_Ascending:
+		input.ScanIndexForward = jsii.Bool(true)
+	default:
+		return errors.Errorf("invalid sort order `%s`", r.Order)
+	}
+
+	condition := r.SortKey
+	if !r.CreatedAfter.IsZero() || !r.CreatedBefore.IsZero() {
+		if condition != nil {
+			return errors.New("cannot combine sort key condition with creation time range")
+		}
+		var err error
+		if condition, err = creationTimeCondition(r.CreatedAfter, r.CreatedBefore); err != nil {
+			return err
+		}
+	}
+	if condition == nil {
+		return nil
+	}
+
+	var expression string
+	input.ExpressionAttributeNames["#sk"] = "id"
+	input.ExpressionAttributeValues[":sk"] = &types.AttributeValueMemberS{Value: condition.Value}
+	switch condition.Operator {
+	case KeyOperator_Equal, KeyOperator_LessThan, KeyOperator_LessThanOrEqual,
+		KeyOperator_GreaterThan, KeyOperator_GreaterThanOrEqual:
+		expression = "#sk " + string(condition.Operator) + " :sk"
+	case KeyOperator_Between:
+		if condition.Until == "" {
+			return errors.New("between sort key condition requires an upper bound")
+		}
+		expression = "#sk BETWEEN :sk AND :skuntil"
+		input.ExpressionAttributeValues[":skuntil"] = &types.AttributeValueMemberS{Value: condition.Until}
+	case KeyOperator_BeginsWith:
+		expression = "begins_with(#sk, :sk)"
+	default:
+		return errors.Errorf("invalid sort key operator `%s`", condition.Operator)
+	}
+
+	input.KeyConditionExpression = jsii.String(*input.KeyConditionExpression + " AND " + expression)
+	return nil
+}
+
+func creationTimeCondition(after time.Time, before time.Time) (*KeyCondition, error) {
+
+	if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+		return nil, errors.New("invalid creation time range")
+	}
+
+	// entity ids are ulids, so their first characters encode the creation time
+	var lower, upper string
+	var err error
+	if !after.IsZero() {
+		if lower, err = ulidBound(after, 0x00); err != nil {
+			return nil, errors.Wrap(err, "invalid creation time lower bound")
+		}
+	}
+	if !before.IsZero() {
+		if upper, err = ulidBound(before.Add(-time.Millisecond), 0xff); err != nil {
+			return nil, errors.Wrap(err, "invalid creation time upper bound")
+		}
+	}
+
+	switch {
+	case lower != "" && upper != "":
+		return &KeyCondition{Operator: KeyOperator_Between, Value: lower, Until: upper}, nil
+	case lower != "":
+		return &KeyCondition{Operator: KeyOperator_GreaterThanOrEqual, Value: lower}, nil
+	default:
+		return &KeyCondition{Operator: KeyOperator_LessThanOrEqual, Value: upper}, nil
+	}
+}
+
+func ulidBound(t time.Time, entropy byte) (string, error) {
+	// ulids only encode milliseconds since 1970 up to ulid.MaxTime
+	if t.Before(time.UnixMilli(0)) {
+		return "", errors.Errorf("time %s is before the ulid epoch", t.Format(time.RFC3339))
+	}
+	var id ulid.ULID
+	if err := id.SetTime(ulid.Timestamp(t)); err != nil {
+		return "", errors.Wrapf(err, "time %s is out of the ulid range", t.Format(time.RFC3339))
+	}
+	if err := id.SetEntropy(bytes.Repeat([]byte{entropy}, 10)); err != nil {
+		return "", errors.Wrap(err, "cannot set ulid entropy")
+	}
+	return id.String(), nil
+}
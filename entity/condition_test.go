@@ -0,0 +1,211 @@
+package entity_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/oklog/ulid/v2"
+)
+
+var rangeEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// seedOrdersAt seeds one order per name, created an hour apart from rangeEpoch
+func seedOrdersAt(t *testing.T, harness *cvxtest.Harness, names ...string) map[string]string {
+	t.Helper()
+	ctx := harness.ExecutionContext(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "seed.v1"}))
+	ids := make(map[string]string)
+	for i, name := range names {
+		created := entity.Create(ctx, &Order{Name: name}).SetIndex("owner", "alice").Execute()
+		item, err := entity.ToDynamodb_Map(created)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := rangeEpoch.Add(time.Duration(i) * time.Hour)
+		id := ulid.MustNew(ulid.Timestamp(at), bytes.NewReader(bytes.Repeat([]byte{byte(i + 1)}, 10))).String()
+		item["id"] = &types.AttributeValueMemberS{Value: id}
+		for key, value := range item {
+			if _, ok := value.(*types.AttributeValueMemberNULL); ok {
+				delete(item, key)
+			}
+		}
+		if err = harness.Store.Put("dyn-cvxtest-cvxtest-statestore", item); err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = id
+	}
+	return ids
+}
+
+type rangeQuery struct {
+	SortKey       *entity.KeyCondition
+	Order         entity.SortOrder
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+func TestQueryRange(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ids := seedOrdersAt(t, harness, "a", "b", "c", "d")
+	hour := func(n int) time.Time {
+		return rangeEpoch.Add(time.Duration(n) * time.Hour)
+	}
+
+	tests := []struct {
+		name     string
+		query    rangeQuery
+		expected []string
+		err      string
+	}{
+		{name: "descending by default", expected: []string{"d", "c", "b", "a"}},
+		{name: "descending", query: rangeQuery{Order: entity.SortOrder_Descending}, expected: []string{"d", "c", "b", "a"}},
+		{name: "ascending", query: rangeQuery{Order: entity.SortOrder_Ascending}, expected: []string{"a", "b", "c", "d"}},
+		{
+			name:     "equal",
+			query:    rangeQuery{SortKey: &entity.KeyCondition{Operator: entity.KeyOperator_Equal, Value: ids["b"]}},
+			expected: []string{"b"},
+		},
+		{
+			name:     "less than",
+			query:    rangeQuery{SortKey: &entity.KeyCondition{Operator: entity.KeyOperator_LessThan, Value: ids["c"]}},
+			expected: []string{"b", "a"},
+		},
+		{
+			name:     "less than or equal",
+			query:    rangeQuery{SortKey: &entity.KeyCondition{Operator: entity.KeyOperator_LessThanOrEqual, Value: ids["c"]}},
+			expected: []string{"c", "b", "a"},
+		},
+		{
+			name: "greater than ascending",
+			query: rangeQuery{
+				SortKey: &entity.KeyCondition{Operator: entity.KeyOperator_GreaterThan, Value: ids["b"]},
+				Order:   entity.SortOrder_Ascending,
+			},
+			expected: []string{"c", "d"},
+		},
+		{
+			name:     "greater than or equal",
+			query:    rangeQuery{SortKey: &entity.KeyCondition{Operator: entity.KeyOperator_GreaterThanOrEqual, Value: ids["b"]}},
+			expected: []string{"d", "c", "b"},
+		},
+		{
+			name: "between ascending",
+			query: rangeQuery{
+				SortKey: &entity.KeyCondition{Operator: entity.KeyOperator_Between, Value: ids["b"], Until: ids["c"]},
+				Order:   entity.SortOrder_Ascending,
+			},
+			expected: []string{"b", "c"},
+		},
+		{
+			name:     "begins with",
+			query:    rangeQuery{SortKey: &entity.KeyCondition{Operator: entity.KeyOperator_BeginsWith, Value: ids["c"][:10]}},
+			expected: []string{"c"},
+		},
+		{
+			name:     "created after",
+			query:    rangeQuery{CreatedAfter: hour(1), Order: entity.SortOrder_Ascending},
+			expected: []string{"b", "c", "d"},
+		},
+		{
+			name:     "created before is exclusive",
+			query:    rangeQuery{CreatedBefore: hour(2), Order: entity.SortOrder_Ascending},
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "created between",
+			query:    rangeQuery{CreatedAfter: hour(1), CreatedBefore: hour(3)},
+			expected: []string{"c", "b"},
+		},
+		{
+			name:     "created at the ulid epoch",
+			query:    rangeQuery{CreatedAfter: time.UnixMilli(0)},
+			expected: []string{"d", "c", "b", "a"},
+		},
+		{
+			name:  "between without upper bound",
+			query: rangeQuery{SortKey: &entity.KeyCondition{Operator: entity.KeyOperator_Between, Value: ids["b"]}},
+			err:   "between sort key condition requires an upper bound",
+		},
+		{
+			name: "sort key with creation time",
+			query: rangeQuery{
+				SortKey:      &entity.KeyCondition{Operator: entity.KeyOperator_GreaterThan, Value: ids["b"]},
+				CreatedAfter: hour(1),
+			},
+			err: "cannot combine sort key condition with creation time range",
+		},
+		{
+			name:  "invalid operator",
+			query: rangeQuery{SortKey: &entity.KeyCondition{Operator: "contains", Value: ids["b"]}},
+			err:   "invalid sort key operator",
+		},
+		{
+			name:  "invalid order",
+			query: rangeQuery{Order: "random"},
+			err:   "invalid sort order",
+		},
+		{
+			name:  "empty creation time range",
+			query: rangeQuery{CreatedAfter: hour(2), CreatedBefore: hour(2)},
+			err:   "invalid creation time range",
+		},
+		{
+			name:  "created after before the ulid epoch",
+			query: rangeQuery{CreatedAfter: time.Date(1969, time.December, 31, 0, 0, 0, 0, time.UTC)},
+			err:   "invalid creation time lower bound",
+		},
+		{
+			name:  "created before the ulid epoch",
+			query: rangeQuery{CreatedBefore: time.UnixMilli(0)},
+			err:   "invalid creation time upper bound",
+		},
+		{
+			name:  "created after the ulid range",
+			query: rangeQuery{CreatedAfter: ulid.Time(ulid.MaxTime()).Add(time.Millisecond)},
+			err:   "invalid creation time lower bound",
+		},
+	}
+
+	queries := map[string]func(ctx context.Context, query rangeQuery) (entity.EntityPage, error){
+		"FindAll": func(ctx context.Context, query rangeQuery) (entity.EntityPage, error) {
+			return entity.FindAll(ctx, &entity.FindAllProps{
+				Domain: "cvxtest", Typename: "Order",
+				SortKey: query.SortKey, Order: query.Order,
+				CreatedAfter: query.CreatedAfter, CreatedBefore: query.CreatedBefore,
+			})
+		},
+		"FindBy": func(ctx context.Context, query rangeQuery) (entity.EntityPage, error) {
+			return entity.FindBy(ctx, &entity.FindByProps{
+				Domain: "cvxtest", Typename: "Order", IndexName: "owner", IndexValue: "alice",
+				SortKey: query.SortKey, Order: query.Order,
+				CreatedAfter: query.CreatedAfter, CreatedBefore: query.CreatedBefore,
+			})
+		},
+	}
+
+	for name, find := range queries {
+		for _, test := range tests {
+			t.Run(name+"/"+test.name, func(t *testing.T) {
+				page, err := find(harness.Context(), test.query)
+				if test.err != "" {
+					if err == nil || !strings.Contains(err.Error(), test.err) {
+						t.Fatalf("expected error `%s`, found %v", test.err, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cvxtest.DiffJSON(test.expected, orderNames(t, page.Items())); diff != "" {
+					t.Fatalf("range mismatch (-expected +actual):\n%s", diff)
+				}
+			})
+		}
+	}
+}
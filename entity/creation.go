@@ -6,6 +6,7 @@ import (
 
 	"github.com/cevixe/sdk/common/reflect"
 	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/trace"
 	"github.com/oklog/ulid/v2"
 )
 
@@ -16,16 +17,22 @@ type Creation interface {
 		eventData interface{},
 	) Creation
 
+	SetIndex(name string, value string) Creation
+
 	Execute() Entity
 }
 
 func Create(ctx context.Context, state interface{}) Creation {
 	cvx := cvxcontext.GetExecutionContenxt(ctx)
+	sc := trace.SpanContextFromContext(ctx)
 	return &creationImpl{
 		Author:      cvx.Author,
 		Trigger:     cvx.Trigger,
 		Transaction: cvx.Transaction,
+		TraceParent: sc.TraceParent(),
+		TraceState:  sc.TraceState,
 		State:       state,
+		NewIndexes:  make(map[string]string),
 	}
 }
 
@@ -33,10 +40,13 @@ type creationImpl struct {
 	Author          string
 	Trigger         string
 	Transaction     string
+	TraceParent     string
+	TraceState      string
 	State           interface{}
 	NewEventType    string
 	NewEventVersion uint64
 	NewEventData    interface{}
+	NewIndexes      map[string]string
 }
 
 func (c *creationImpl) SetEvent(
@@ -54,8 +64,16 @@ func (c *creationImpl) SetEvent(
 	return c
 }
 
+func (c *creationImpl) SetIndex(name string, value string) Creation {
+	c.NewIndexes[name] = value
+	return c
+}
+
 func (c *creationImpl) Execute() Entity {
 	now := time.Now()
+	indexes := make(map[string]string)
+	mergeIndexes(indexes, getStateIndexes(c.State))
+	mergeIndexes(indexes, c.NewIndexes)
 	return &entityImpl{
 		EntityID:         ulid.Make().String(),
 		EntityType:       reflect.GetTypeName(c.State),
@@ -66,10 +84,14 @@ func (c *creationImpl) Execute() Entity {
 		EntityUpdatedAt:  now,
 		EntityCreatedBy:  c.Author,
 		EntityCreatedAt:  now,
+		EntityIndexes:    getIndexNames(indexes),
+		IndexValues:      indexes,
 		LastTransaction:  c.Transaction,
 		LastEventTrigger: c.Trigger,
 		LastEventType:    c.NewEventType,
 		LastEventVersion: c.NewEventVersion,
 		LastEventData:    c.NewEventData,
+		LastTraceParent:  c.TraceParent,
+		LastTraceState:   c.TraceState,
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/trace"
 )
 
 type Deletion interface {
@@ -21,6 +22,8 @@ type deletionImpl struct {
 	Author          string
 	Trigger         string
 	Transaction     string
+	TraceParent     string
+	TraceState      string
 	Target          *entityImpl
 	NewEventType    string
 	NewEventVersion uint64
@@ -29,10 +32,13 @@ type deletionImpl struct {
 
 func newDeletion(ctx context.Context, target *entityImpl) Deletion {
 	cvx := cvxcontext.GetExecutionContenxt(ctx)
+	sc := trace.SpanContextFromContext(ctx)
 	return &deletionImpl{
 		Author:      cvx.Author,
 		Trigger:     cvx.Trigger,
 		Transaction: cvx.Transaction,
+		TraceParent: sc.TraceParent(),
+		TraceState:  sc.TraceState,
 		Target:      target,
 	}
 }
@@ -63,10 +69,14 @@ func (d *deletionImpl) Execute() Entity {
 		EntityUpdatedAt:  time.Now(),
 		EntityCreatedAt:  d.Target.CreatedAt(),
 		EntityCreatedBy:  d.Target.CreatedBy(),
+		EntityIndexes:    d.Target.EntityIndexes,
+		IndexValues:      d.Target.IndexValues,
 		LastTransaction:  d.Transaction,
 		LastEventTrigger: d.Trigger,
 		LastEventType:    d.NewEventType,
 		LastEventVersion: d.NewEventVersion,
 		LastEventData:    d.NewEventData,
+		LastTraceParent:  d.TraceParent,
+		LastTraceState:   d.TraceState,
 	}
 }
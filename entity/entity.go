@@ -16,6 +16,7 @@ type Entity interface {
 	Type() string
 	Version() uint64
 	Indexes() []string
+	IndexValue(name string) string
 	Status() EntityStatus
 	Data(interface{}) error
 	UpdatedAt() time.Time
@@ -28,21 +29,26 @@ type Entity interface {
 }
 
 type entityImpl struct {
-	EntityType       string       `json:"type"`
-	EntityID         string       `json:"id"`
-	EntityVersion    uint64       `json:"version"`
-	EntityStatus     EntityStatus `json:"status"`
-	EntityData       interface{}  `json:"data"`
-	EntityUpdatedBy  string       `json:"updatedBy"`
-	EntityUpdatedAt  time.Time    `json:"updatedAt"`
-	EntityCreatedBy  string       `json:"createdBy"`
-	EntityCreatedAt  time.Time    `json:"createdAt"`
-	EntityIndexes    []string     `json:"indexes"`
-	LastTransaction  string       `json:"lastTransaction"`
-	LastEventTrigger string       `json:"lastEventTrigger,omitempty"`
-	LastEventType    string       `json:"lastEventType,omitempty"`
-	LastEventVersion uint64       `json:"lastEventVersion,omitempty"`
-	LastEventData    interface{}  `json:"lastEventData,omitempty"`
+	EntityType       string            `json:"type"`
+	EntityID         string            `json:"id"`
+	EntityVersion    uint64            `json:"version"`
+	EntityStatus     EntityStatus      `json:"status"`
+	EntityData       interface{}       `json:"data"`
+	EntityUpdatedBy  string            `json:"updatedBy"`
+	EntityUpdatedAt  time.Time         `json:"updatedAt"`
+	EntityCreatedBy  string            `json:"createdBy"`
+	EntityCreatedAt  time.Time         `json:"createdAt"`
+	EntityIndexes    []string          `json:"indexes"`
+	IndexValues      map[string]string `json:"indexValues,omitempty"`
+	RemovedIndexes   []string          `json:"removedIndexes,omitempty"`
+	Snapshot         bool              `json:"snapshot,omitempty"`
+	LastTransaction  string            `json:"lastTransaction"`
+	LastEventTrigger string            `json:"lastEventTrigger,omitempty"`
+	LastEventType    string            `json:"lastEventType,omitempty"`
+	LastEventVersion uint64            `json:"lastEventVersion,omitempty"`
+	LastEventData    interface{}       `json:"lastEventData,omitempty"`
+	LastTraceParent  string            `json:"lastTraceParent,omitempty"`
+	LastTraceState   string            `json:"lastTraceState,omitempty"`
 }
 
 type EntityStatus string
@@ -72,6 +78,10 @@ func (e *entityImpl) Indexes() []string {
 	return e.EntityIndexes
 }
 
+func (e *entityImpl) IndexValue(name string) string {
+	return e.IndexValues[name]
+}
+
 func (e *entityImpl) Data(obj interface{}) error {
 	buffer, err := json.Marshal(e.EntityData)
 	if err != nil {
@@ -134,6 +144,12 @@ func (e *entityImpl) LastEvent() (message.Event, error) {
 	eventMap["author"] = e.EntityUpdatedBy
 	eventMap["trigger"] = e.LastEventTrigger
 	eventMap["transaction"] = e.LastTransaction
+	if e.LastTraceParent != "" {
+		eventMap["traceparent"] = e.LastTraceParent
+	}
+	if e.LastTraceState != "" {
+		eventMap["tracestate"] = e.LastTraceState
+	}
 
 	eventJson, err := json.Marshal(eventMap)
 	if err != nil {
@@ -3,19 +3,25 @@ package entity
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/jsii-runtime-go"
 	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/trace"
 	"github.com/pkg/errors"
 )
 
 type FindAllProps struct {
-	Domain    string `field:"required"`
-	Typename  string `field:"required"`
-	NextToken string `field:"optional"`
-	Limit     uint64 `field:"optional"`
+	Domain        string        `field:"required"`
+	Typename      string        `field:"required"`
+	NextToken     string        `field:"optional"`
+	Limit         uint64        `field:"optional"`
+	SortKey       *KeyCondition `field:"optional"`
+	Order         SortOrder     `field:"optional"`
+	CreatedAfter  time.Time     `field:"optional"`
+	CreatedBefore time.Time     `field:"optional"`
 }
 
 func FindAll(ctx context.Context, props *FindAllProps) (EntityPage, error) {
@@ -36,7 +42,16 @@ func FindAll(ctx context.Context, props *FindAllProps) (EntityPage, error) {
 				Value: fmt.Sprintf("%s#%s", EntityStatus_Alive, props.Typename),
 			},
 		},
-		ScanIndexForward: jsii.Bool(false),
+	}
+
+	err := applyQueryRange(input, &queryRange{
+		SortKey:       props.SortKey,
+		Order:         props.Order,
+		CreatedAfter:  props.CreatedAfter,
+		CreatedBefore: props.CreatedBefore,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	if props.Limit == 0 {
@@ -47,18 +62,14 @@ func FindAll(ctx context.Context, props *FindAllProps) (EntityPage, error) {
 		input.Limit = &customLimit
 	}
 
-	if props.NextToken != "" {
-		input.ExclusiveStartKey = map[string]types.AttributeValue{
-			"__space": &types.AttributeValueMemberS{
-				Value: fmt.Sprintf("%s#%s", EntityStatus_Alive, props.Typename),
-			},
-			"id": &types.AttributeValueMemberS{
-				Value: props.NextToken,
-			},
-		}
+	if input.ExclusiveStartKey, err = decodePageToken(ctx, input, props.NextToken); err != nil {
+		return nil, err
 	}
 
+	ctx, span := trace.Start(ctx, "dynamodb.Query", "table", table)
 	output, err := cvxini.DynamodbClient.Query(ctx, input)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot get dynamodb entity by id")
 	}
@@ -71,10 +82,9 @@ func FindAll(ctx context.Context, props *FindAllProps) (EntityPage, error) {
 		}
 		entities = append(entities, entity)
 	}
-	nextToken := ""
-	if len(output.LastEvaluatedKey) > 0 {
-		attribute := output.LastEvaluatedKey["id"].(*types.AttributeValueMemberS)
-		nextToken = attribute.Value
+	nextToken, err := encodePageToken(ctx, input, output.LastEvaluatedKey)
+	if err != nil {
+		return nil, err
 	}
 
 	return NewPage(entities, nextToken), nil
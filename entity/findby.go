@@ -3,23 +3,32 @@ package entity
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/jsii-runtime-go"
 	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/trace"
 	"github.com/pkg/errors"
 )
 
 type FindByProps struct {
-	Domain     string `field:"required"`
-	Typename   string `field:"required"`
-	IndexName  string `field:"required"`
-	IndexValue string `field:"required"`
-	NextToken  string `field:"optional"`
-	Limit      uint64 `field:"optional"`
+	Domain        string        `field:"required"`
+	Typename      string        `field:"required"`
+	IndexName     string        `field:"required"`
+	IndexValue    string        `field:"required"`
+	NextToken     string        `field:"optional"`
+	Limit         uint64        `field:"optional"`
+	SortKey       *KeyCondition `field:"optional"`
+	Order         SortOrder     `field:"optional"`
+	CreatedAfter  time.Time     `field:"optional"`
+	CreatedBefore time.Time     `field:"optional"`
+	MaxReads      uint64        `field:"optional"`
 }
 
+const findByDefaultMaxReads = 1000
+
 func FindBy(ctx context.Context, props *FindByProps) (EntityPage, error) {
 
 	cvxini := cvxcontext.GetInitContenxt(ctx)
@@ -31,10 +40,11 @@ func FindBy(ctx context.Context, props *FindByProps) (EntityPage, error) {
 		TableName:              jsii.String(table),
 		IndexName:              jsii.String(props.IndexName),
 		KeyConditionExpression: jsii.String("#index = :value"),
-		FilterExpression:       jsii.String("#type = :type"),
+		FilterExpression:       jsii.String("#type = :type AND attribute_not_exists(#snapshot)"),
 		ExpressionAttributeNames: map[string]string{
-			"#index": partitionKey,
-			"#type":  "__typename",
+			"#index":    partitionKey,
+			"#type":     "__typename",
+			"#snapshot": "__snapshot",
 		},
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":value": &types.AttributeValueMemberS{
@@ -44,45 +54,80 @@ func FindBy(ctx context.Context, props *FindByProps) (EntityPage, error) {
 				Value: props.Typename,
 			},
 		},
-		ScanIndexForward: jsii.Bool(false),
 	}
 
-	if props.Limit == 0 {
-		var defaultLimit int32 = 20
-		input.Limit = &defaultLimit
-	} else {
-		var customLimit int32 = int32(props.Limit)
-		input.Limit = &customLimit
+	err := applyQueryRange(input, &queryRange{
+		SortKey:       props.SortKey,
+		Order:         props.Order,
+		CreatedAfter:  props.CreatedAfter,
+		CreatedBefore: props.CreatedBefore,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if props.NextToken != "" {
-		input.ExclusiveStartKey = map[string]types.AttributeValue{
-			partitionKey: &types.AttributeValueMemberS{
-				Value: props.IndexValue,
-			},
-			"id": &types.AttributeValueMemberS{
-				Value: props.NextToken,
-			},
-		}
+	limit := props.Limit
+	if limit == 0 {
+		limit = 20
+	}
+	maxReads := props.MaxReads
+	if maxReads == 0 {
+		maxReads = findByDefaultMaxReads
+	}
+	if maxReads < limit {
+		maxReads = limit
 	}
 
-	output, err := cvxini.DynamodbClient.Query(ctx, input)
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot get dynamodb entity by id")
+	if input.ExclusiveStartKey, err = decodePageToken(ctx, input, props.NextToken); err != nil {
+		return nil, err
 	}
 
-	entities := make([]Entity, 0)
-	for _, item := range output.Items {
-		entity, err := FromDynamodb_TableMap(item)
+	// the type filter runs after the query limit, so keep reading until the
+	// page is filled or the read budget is exhausted
+	entities := make([]Entity, 0, limit)
+	var reads uint64
+	var lastKey map[string]types.AttributeValue
+	for {
+		var pageLimit int32 = int32(limit)
+		if remaining := maxReads - reads; remaining < limit {
+			pageLimit = int32(remaining)
+		}
+		input.Limit = &pageLimit
+
+		spanCtx, span := trace.Start(ctx, "dynamodb.Query", "table", table)
+		output, err := cvxini.DynamodbClient.Query(spanCtx, input)
+		span.RecordError(err)
+		span.End()
 		if err != nil {
-			return nil, errors.Wrap(err, "cannot read dynamodb entity map")
+			return nil, errors.Wrap(err, "cannot get dynamodb entity by id")
+		}
+		reads += uint64(output.ScannedCount)
+		lastKey = output.LastEvaluatedKey
+
+		for idx, item := range output.Items {
+			entity, err := FromDynamodb_TableMap(item)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot read dynamodb entity map")
+			}
+			entities = append(entities, entity)
+			if uint64(len(entities)) == limit && idx < len(output.Items)-1 {
+				lastKey = map[string]types.AttributeValue{
+					partitionKey: item[partitionKey],
+					"id":         item["id"],
+				}
+				break
+			}
 		}
-		entities = append(entities, entity)
+
+		if uint64(len(entities)) >= limit || len(lastKey) == 0 || reads >= maxReads {
+			break
+		}
+		input.ExclusiveStartKey = lastKey
 	}
-	nextToken := ""
-	if len(output.LastEvaluatedKey) > 0 {
-		attribute := output.LastEvaluatedKey["id"].(*types.AttributeValueMemberS)
-		nextToken = attribute.Value
+
+	nextToken, err := encodePageToken(ctx, input, lastKey)
+	if err != nil {
+		return nil, err
 	}
 
 	return NewPage(entities, nextToken), nil
@@ -0,0 +1,122 @@
+package entity_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+)
+
+// seedOwnerIndex seeds orders (O) and invoices (I) sharing the owner index in
+// the given creation order, orders are named o1, o2... as they are created
+func seedOwnerIndex(t *testing.T, harness *cvxtest.Harness, layout string) {
+	ctx := harness.ExecutionContext(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "seed.v1"}))
+	orders := 0
+	for _, kind := range layout {
+		var item entity.Entity
+		if kind == 'O' {
+			orders++
+			item = entity.Create(ctx, &Order{Name: fmt.Sprintf("o%d", orders)}).SetIndex("owner", "alice").Execute()
+		} else {
+			item = entity.Create(ctx, &Invoice{Number: "F-1"}).SetIndex("owner", "alice").Execute()
+		}
+		if err := harness.Seed(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func orderNames(t *testing.T, entities []entity.Entity) []string {
+	names := make([]string, 0, len(entities))
+	for _, item := range entities {
+		order := &Order{}
+		if err := item.Data(order); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, order.Name)
+	}
+	return names
+}
+
+func TestFindBy_FillsPages(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		layout   string
+		limit    uint64
+		maxReads uint64
+		pages    [][]string
+		queries  int
+	}{
+		{
+			name:    "pages filled under the type filter",
+			layout:  "OIOIOIOIOIOI",
+			limit:   2,
+			pages:   [][]string{{"o6", "o5"}, {"o4", "o3"}, {"o2", "o1"}},
+			queries: 6,
+		},
+		{
+			name:    "overshooting read truncated to the limit",
+			layout:  "OOOOI",
+			limit:   2,
+			pages:   [][]string{{"o4", "o3"}, {"o2", "o1"}},
+			queries: 3,
+		},
+		{
+			name:     "read budget returns partial pages",
+			layout:   "OIIIIIIIIO",
+			limit:    2,
+			maxReads: 4,
+			pages:    [][]string{{"o2"}, {}, {"o1"}},
+			queries:  5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			store := cvxtest.NewStore()
+			queries := 0
+			harness := cvxtest.NewHarness(&cvxtest.HarnessProps{
+				Store: store,
+				DynamodbClient: &cvxtest.ClientStub{Store: store, OnQuery: func(ctx context.Context, params *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+					queries++
+					return store.Query(ctx, params)
+				}},
+			})
+			seedOwnerIndex(t, harness, test.layout)
+			ctx := harness.Context()
+
+			pages := make([][]string, 0)
+			nextToken := ""
+			for {
+				page, err := entity.FindBy(ctx, &entity.FindByProps{
+					Domain:     "cvxtest",
+					Typename:   "Order",
+					IndexName:  "owner",
+					IndexValue: "alice",
+					Limit:      test.limit,
+					MaxReads:   test.maxReads,
+					NextToken:  nextToken,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				pages = append(pages, orderNames(t, page.Items()))
+				if nextToken = page.NextToken(); nextToken == "" {
+					break
+				}
+			}
+
+			if diff := cvxtest.DiffJSON(test.pages, pages); diff != "" {
+				t.Fatalf("pages mismatch (-expected +actual):\n%s", diff)
+			}
+			if queries != test.queries {
+				t.Fatalf("expected %d queries, found %d", test.queries, queries)
+			}
+		})
+	}
+}
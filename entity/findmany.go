@@ -0,0 +1,150 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/trace"
+	"github.com/pkg/errors"
+)
+
+const (
+	batchGetMaxKeys     = 100
+	batchGetMaxAttempts = 8
+	batchGetBackoffBase = 50 * time.Millisecond
+	batchGetBackoffMax  = 2 * time.Second
+)
+
+type FindManyProps struct {
+	Domain   string   `field:"required"`
+	Typename string   `field:"required"`
+	IDs      []string `field:"required"`
+}
+
+type EntityBatch interface {
+	Items() []Entity
+	Missing() []string
+}
+
+type entityBatchImpl struct {
+	BatchItems   []Entity
+	BatchMissing []string
+}
+
+func (b *entityBatchImpl) Items() []Entity {
+	return b.BatchItems
+}
+
+func (b *entityBatchImpl) Missing() []string {
+	return b.BatchMissing
+}
+
+func FindMany(ctx context.Context, props *FindManyProps) (EntityBatch, error) {
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+	table := fmt.Sprintf("dyn-%s-%s-statestore", cvxini.AppName, props.Domain)
+
+	ids := make([]string, 0, len(props.IDs))
+	requested := make(map[string]bool)
+	for _, id := range props.IDs {
+		if !requested[id] {
+			requested[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	found := make(map[string]Entity)
+	for start := 0; start < len(ids); start += batchGetMaxKeys {
+		end := start + batchGetMaxKeys
+		if end > len(ids) {
+			end = len(ids)
+		}
+		items, err := batchGetEntities(ctx, table, ids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			entity, err := FromDynamodb_TableMap(item)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot read dynamodb entity map")
+			}
+			if entity.Type() != props.Typename {
+				return nil, errors.New("invalid entity typename")
+			}
+			if IsSnapshot(entity) {
+				continue
+			}
+			found[entity.ID()] = entity
+		}
+	}
+
+	batch := &entityBatchImpl{
+		BatchItems:   make([]Entity, 0, len(ids)),
+		BatchMissing: make([]string, 0),
+	}
+	for _, id := range ids {
+		if entity, ok := found[id]; ok {
+			batch.BatchItems = append(batch.BatchItems, entity)
+		} else {
+			batch.BatchMissing = append(batch.BatchMissing, id)
+		}
+	}
+	return batch, nil
+}
+
+func batchGetEntities(ctx context.Context, table string, ids []string) ([]map[string]types.AttributeValue, error) {
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+
+	keys := make([]map[string]types.AttributeValue, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		})
+	}
+	request := map[string]types.KeysAndAttributes{
+		table: {Keys: keys},
+	}
+
+	items := make([]map[string]types.AttributeValue, 0, len(ids))
+	for attempt := 0; ; attempt++ {
+
+		spanCtx, span := trace.Start(ctx, "dynamodb.BatchGetItem", "table", table)
+		output, err := cvxini.DynamodbClient.BatchGetItem(spanCtx, &dynamodb.BatchGetItemInput{RequestItems: request})
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot batch get dynamodb entities by id")
+		}
+
+		items = append(items, output.Responses[table]...)
+		unprocessed, ok := output.UnprocessedKeys[table]
+		if !ok || len(unprocessed.Keys) == 0 {
+			return items, nil
+		}
+		if attempt+1 >= batchGetMaxAttempts {
+			return nil, errors.Errorf("cannot batch get dynamodb entities, %d keys unprocessed after %d attempts",
+				len(unprocessed.Keys), batchGetMaxAttempts)
+		}
+		request = map[string]types.KeysAndAttributes{table: unprocessed}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "batch get dynamodb entities canceled")
+		case <-time.After(batchGetBackoff(attempt)):
+		}
+	}
+}
+
+func batchGetBackoff(attempt int) time.Duration {
+	ceiling := batchGetBackoffBase << attempt
+	if ceiling <= 0 || ceiling > batchGetBackoffMax {
+		ceiling = batchGetBackoffMax
+	}
+	return time.Duration(rand.Int63n(int64(ceiling))) + time.Millisecond
+}
@@ -0,0 +1,122 @@
+package entity_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/pkg/errors"
+)
+
+// newThrottledHarness leaves half of the requested keys unprocessed on the
+// first throttled batch calls and records the number of keys of every call
+func newThrottledHarness(throttled int, calls *[]int) *cvxtest.Harness {
+	store := cvxtest.NewStore()
+	client := &cvxtest.ClientStub{Store: store}
+	client.OnBatchGetItem = func(ctx context.Context, params *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+
+		unprocessed := make(map[string]types.KeysAndAttributes)
+		request := make(map[string]types.KeysAndAttributes)
+		for table, keys := range params.RequestItems {
+			*calls = append(*calls, len(keys.Keys))
+			if throttled == 0 {
+				request[table] = keys
+				continue
+			}
+			half := len(keys.Keys) / 2
+			request[table] = types.KeysAndAttributes{Keys: keys.Keys[:half]}
+			unprocessed[table] = types.KeysAndAttributes{Keys: keys.Keys[half:]}
+		}
+		if throttled > 0 {
+			throttled--
+		}
+
+		output, err := store.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+		if err != nil {
+			return nil, err
+		}
+		output.UnprocessedKeys = unprocessed
+		return output, nil
+	}
+	return cvxtest.NewHarness(&cvxtest.HarnessProps{Store: store, DynamodbClient: client})
+}
+
+func TestFindMany(t *testing.T) {
+
+	calls := make([]int, 0)
+	harness := newThrottledHarness(2, &calls)
+	ids := make([]string, 0)
+	for idx := 0; idx < 150; idx++ {
+		order := harness.NewEntity(&Order{Name: fmt.Sprintf("order-%03d", idx)})
+		if err := harness.Seed(order); err != nil {
+			t.Fatal(err)
+		}
+		ids = append([]string{order.ID()}, ids...)
+	}
+
+	requested := append(append([]string{}, ids[:75]...), "missing", ids[0])
+	requested = append(requested, ids[75:]...)
+	batch, err := entity.FindMany(harness.Context(), &entity.FindManyProps{
+		Domain:   "cvxtest",
+		Typename: "Order",
+		IDs:      requested,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cvxtest.DiffJSON(ids, entityIDs(batch.Items())); diff != "" {
+		t.Fatalf("items mismatch (-expected +actual):\n%s", diff)
+	}
+	if diff := cvxtest.DiffJSON([]string{"missing"}, batch.Missing()); diff != "" {
+		t.Fatalf("missing mismatch (-expected +actual):\n%s", diff)
+	}
+	// two chunks of at most 100 keys, the first one retried twice
+	if diff := cvxtest.DiffJSON([]int{100, 50, 25, 51}, calls); diff != "" {
+		t.Fatalf("batch calls mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestFindMany_InvalidTypename(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	invoice := harness.NewEntity(&Invoice{Number: "F-1"})
+	if err := harness.Seed(invoice); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := entity.FindMany(harness.Context(), &entity.FindManyProps{
+		Domain:   "cvxtest",
+		Typename: "Order",
+		IDs:      []string{invoice.ID()},
+	})
+	if err == nil {
+		t.Fatalf("expected invalid typename error")
+	}
+}
+
+func TestFindMany_CanceledRetry(t *testing.T) {
+
+	harness := newThrottledHarness(1000, &[]int{})
+	order := harness.NewEntity(&Order{Name: "book"})
+	if err := harness.Seed(order); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(harness.Context(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := entity.FindMany(ctx, &entity.FindManyProps{
+		Domain:   "cvxtest",
+		Typename: "Order",
+		IDs:      []string{order.ID(), "missing"},
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, found %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/jsii-runtime-go"
 	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/trace"
 	"github.com/pkg/errors"
 )
 
@@ -19,6 +20,15 @@ type FindOneProps struct {
 
 func FindOne(ctx context.Context, props *FindOneProps) (Entity, error) {
 
+	entity, err := getEntity(ctx, props)
+	if err != nil || IsSnapshot(entity) {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func getEntity(ctx context.Context, props *FindOneProps) (Entity, error) {
+
 	cvxini := cvxcontext.GetInitContenxt(ctx)
 	app := cvxini.AppName
 	table := fmt.Sprintf("dyn-%s-%s-statestore", app, props.Domain)
@@ -30,7 +40,10 @@ func FindOne(ctx context.Context, props *FindOneProps) (Entity, error) {
 		},
 	}
 
+	ctx, span := trace.Start(ctx, "dynamodb.GetItem", "table", table)
 	output, err := cvxini.DynamodbClient.GetItem(ctx, input)
+	span.RecordError(err)
+	span.End()
 
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot get dynamodb entity by id")
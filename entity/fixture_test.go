@@ -0,0 +1,10 @@
+package entity_test
+
+type Order struct {
+	Name    string `json:"name"`
+	Shipped bool   `json:"shipped"`
+}
+
+type Invoice struct {
+	Number string `json:"number"`
+}
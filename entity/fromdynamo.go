@@ -3,6 +3,7 @@ package entity
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	tablevalue "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -95,15 +96,24 @@ func imageMapToEntityMap(imageMap map[string]interface{}) map[string]interface{}
 	entityMap["lastEventType"] = imageMap["__eventtype"]
 	entityMap["lastEventVersion"] = imageMap["__eventversion"]
 	entityMap["lastEventData"] = imageMap["__eventdata"]
+	entityMap["lastTraceParent"] = imageMap["__traceparent"]
+	entityMap["lastTraceState"] = imageMap["__tracestate"]
+	entityMap["snapshot"] = imageMap["__snapshot"]
 
 	indexes := make([]string, 0)
-	for key := range imageMap {
+	indexValues := make(map[string]interface{})
+	for key, value := range imageMap {
 		if strings.HasPrefix(key, "__") &&
 			strings.HasSuffix(key, "-pk") {
-			indexes = append(indexes, key[2:len(key)-3])
+			name := key[2 : len(key)-3]
+			indexes = append(indexes, name)
+			indexValues[name] = value
+			delete(imageMap, key)
 		}
 	}
+	sort.Strings(indexes)
 	entityMap["indexes"] = indexes
+	entityMap["indexValues"] = indexValues
 
 	metadataFields := []string{
 		"__typename",
@@ -120,6 +130,9 @@ func imageMapToEntityMap(imageMap map[string]interface{}) map[string]interface{}
 		"__eventtype",
 		"__eventversion",
 		"__eventdata",
+		"__traceparent",
+		"__tracestate",
+		"__snapshot",
 	}
 
 	for _, field := range metadataFields {
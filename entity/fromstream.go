@@ -24,3 +24,31 @@ func FromStream(input events.DynamoDBEventRecord) (Entity, error) {
 
 	return entity, nil
 }
+
+func FromStreamChange(input events.DynamoDBEventRecord) (Entity, Entity, error) {
+
+	if input.EventName == "REMOVE" {
+		return nil, nil, errors.New("physical record deletion not allowed")
+	}
+
+	dynRecord, err := dynamodb.FromDynamoDBEventRecord(input)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid dynamodb event record")
+	}
+
+	newEntity, err := FromDynamodb_StreamMap(dynRecord.Dynamodb.NewImage)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot unmarshal dynamodb stream new image to entity")
+	}
+
+	if len(dynRecord.Dynamodb.OldImage) == 0 {
+		return nil, newEntity, nil
+	}
+
+	oldEntity, err := FromDynamodb_StreamMap(dynRecord.Dynamodb.OldImage)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot unmarshal dynamodb stream old image to entity")
+	}
+
+	return oldEntity, newEntity, nil
+}
@@ -0,0 +1,282 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/jsii-runtime-go"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/trace"
+	"github.com/pkg/errors"
+	"github.com/stoewer/go-strcase"
+)
+
+var ErrReducerRequired = errors.New("state reducer required for custom entity events")
+
+// StateReducer rebuilds the entity state from its events, a nil state marks
+// the entity as deleted
+type StateReducer func(state map[string]interface{}, event message.Event) (map[string]interface{}, error)
+
+type FindHistoryProps struct {
+	Typename   string `field:"required"`
+	ID         string `field:"required"`
+	Descending bool   `field:"optional"`
+	NextToken  string `field:"optional"`
+	Limit      uint64 `field:"optional"`
+}
+
+// FindAsOfProps without a Reducer only replays the default entity events,
+// created.v1 and updated.v1 replace the state with the event data and
+// deleted.v1 marks the entity as deleted. Custom event data is not the
+// entity state, so histories with custom events fail with ErrReducerRequired,
+// and reusing default event types with custom data is not supported.
+type FindAsOfProps struct {
+	Typename string       `field:"required"`
+	ID       string       `field:"required"`
+	Time     time.Time    `field:"required"`
+	Reducer  StateReducer `field:"optional"`
+}
+
+type EventPage interface {
+	Items() []message.Event
+	NextToken() string
+}
+
+type eventPageImpl struct {
+	PageItems     []message.Event
+	PageNextToken string
+}
+
+func (p *eventPageImpl) Items() []message.Event {
+	return p.PageItems
+}
+
+func (p *eventPageImpl) NextToken() string {
+	return p.PageNextToken
+}
+
+func FindHistory(ctx context.Context, props *FindHistoryProps) (EventPage, error) {
+
+	input := newHistoryQueryInput(ctx, props.Typename, props.ID)
+	input.ScanIndexForward = jsii.Bool(!props.Descending)
+
+	if props.Limit == 0 {
+		var defaultLimit int32 = 20
+		input.Limit = &defaultLimit
+	} else {
+		var customLimit int32 = int32(props.Limit)
+		input.Limit = &customLimit
+	}
+
+	startKey, err := decodePageToken(ctx, input, props.NextToken)
+	if err != nil {
+		return nil, err
+	}
+	input.ExclusiveStartKey = startKey
+
+	output, err := queryHistory(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]message.Event, 0, len(output.Items))
+	for _, item := range output.Items {
+		event, err := message.FromDynamodb_TableMap(item)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read dynamodb event map")
+		}
+		events = append(events, event)
+	}
+	nextToken, err := encodePageToken(ctx, input, output.LastEvaluatedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventPageImpl{PageItems: events, PageNextToken: nextToken}, nil
+}
+
+func FindAsOf(ctx context.Context, props *FindAsOfProps) (Entity, error) {
+
+	reducer := props.Reducer
+	if reducer == nil {
+		reducer = newDefaultStateReducer(props.Typename)
+	}
+
+	input := newHistoryQueryInput(ctx, props.Typename, props.ID)
+	input.ScanIndexForward = jsii.Bool(true)
+
+	var current *entityImpl
+	var state map[string]interface{}
+	for {
+		output, err := queryHistory(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range output.Items {
+			event, err := message.FromDynamodb_TableMap(item)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot read dynamodb event map")
+			}
+			if event.Time().After(props.Time) {
+				return asOfEntity(current), nil
+			}
+
+			previous := state
+			state, err = reducer(state, event)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot reduce event `%s/%s`", event.Source(), event.ID())
+			}
+			if current, err = applyHistoryEvent(props.Typename, props.ID, current, previous, state, event); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			return asOfEntity(current), nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+func asOfEntity(current *entityImpl) Entity {
+	if current == nil {
+		return nil
+	}
+	return current
+}
+
+func newDefaultStateReducer(typename string) StateReducer {
+	return func(state map[string]interface{}, event message.Event) (map[string]interface{}, error) {
+
+		eType, eVersion, err := parseEventType(typename, event.Type())
+		if err != nil {
+			return nil, err
+		}
+		if eVersion != 1 {
+			return nil, errors.Wrapf(ErrReducerRequired, "event type `%s`", event.Type())
+		}
+
+		switch eType {
+		case "created", "updated":
+			data := make(map[string]interface{})
+			if err = event.Data(&data); err != nil {
+				return nil, errors.Wrap(err, "cannot read event data")
+			}
+			return data, nil
+		case "deleted":
+			return nil, nil
+		default:
+			return nil, errors.Wrapf(ErrReducerRequired, "event type `%s`", event.Type())
+		}
+	}
+}
+
+func applyHistoryEvent(
+	typename string,
+	id string,
+	current *entityImpl,
+	previous map[string]interface{},
+	state map[string]interface{},
+	event message.Event,
+) (*entityImpl, error) {
+
+	version, err := strconv.ParseUint(event.ID(), 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid entity event id `%s`", event.ID())
+	}
+	eType, eVersion, err := parseEventType(typename, event.Type())
+	if err != nil {
+		return nil, err
+	}
+	eventData := make(map[string]interface{})
+	if err = event.Data(&eventData); err != nil {
+		return nil, errors.Wrap(err, "cannot read event data")
+	}
+	traceParent, traceState := message.GetTraceContext(event)
+
+	next := &entityImpl{
+		EntityID:         id,
+		EntityType:       typename,
+		EntityVersion:    version,
+		EntityStatus:     EntityStatus_Alive,
+		EntityData:       state,
+		EntityUpdatedBy:  event.Author(),
+		EntityUpdatedAt:  event.Time(),
+		EntityCreatedBy:  event.Author(),
+		EntityCreatedAt:  event.Time(),
+		EntityIndexes:    make([]string, 0),
+		LastTransaction:  event.Transaction(),
+		LastEventTrigger: event.Trigger(),
+		LastEventType:    eType,
+		LastEventVersion: eVersion,
+		LastEventData:    eventData,
+		LastTraceParent:  traceParent,
+		LastTraceState:   traceState,
+	}
+	if current != nil {
+		next.EntityCreatedBy = current.EntityCreatedBy
+		next.EntityCreatedAt = current.EntityCreatedAt
+	}
+	if state == nil {
+		next.EntityStatus = EntityStatus_Dead
+		next.EntityData = previous
+	}
+	return next, nil
+}
+
+func parseEventType(typename string, eventType string) (string, uint64, error) {
+	prefix := fmt.Sprintf("%s.", strcase.KebabCase(typename))
+	eType := strings.TrimPrefix(eventType, prefix)
+	separator := strings.LastIndex(eType, ".v")
+	if separator < 0 {
+		return "", 0, errors.Errorf("invalid entity event type `%s`", eventType)
+	}
+	eVersion, err := strconv.ParseUint(eType[separator+2:], 10, 64)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "invalid entity event type `%s`", eventType)
+	}
+	return eType[:separator], eVersion, nil
+}
+
+func getHistorySource(typename string, id string) string {
+	return fmt.Sprintf("/%s/%s", strcase.KebabCase(typename), id)
+}
+
+func newHistoryQueryInput(ctx context.Context, typename string, id string) *dynamodb.QueryInput {
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+	table := fmt.Sprintf("dyn-%s-core-eventstore", cvxini.AppName)
+
+	return &dynamodb.QueryInput{
+		TableName:              jsii.String(table),
+		KeyConditionExpression: jsii.String("#source = :source"),
+		ExpressionAttributeNames: map[string]string{
+			"#source": "source",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":source": &types.AttributeValueMemberS{
+				Value: getHistorySource(typename, id),
+			},
+		},
+	}
+}
+
+func queryHistory(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+	ctx, span := trace.Start(ctx, "dynamodb.Query", "table", *input.TableName)
+	output, err := cvxini.DynamodbClient.Query(ctx, input)
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot query dynamodb entity history")
+	}
+	return output, nil
+}
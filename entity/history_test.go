@@ -0,0 +1,197 @@
+package entity_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/pkg/errors"
+)
+
+// seedHistory seeds the last event of every entity version and returns the
+// event times, consecutive versions are kept apart in time
+func seedHistory(t *testing.T, harness *cvxtest.Harness, versions ...entity.Entity) []time.Time {
+	times := make([]time.Time, 0, len(versions))
+	for _, version := range versions {
+		event, err := version.LastEvent()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = harness.Seed(event); err != nil {
+			t.Fatal(err)
+		}
+		times = append(times, event.Time())
+	}
+	return times
+}
+
+func nextVersion() {
+	time.Sleep(2 * time.Millisecond)
+}
+
+func eventTypes(events []message.Event) []string {
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type())
+	}
+	return types
+}
+
+func TestFindHistory(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ctx := harness.ExecutionContext(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "place-order.v1"}))
+
+	created := entity.Create(ctx, &Order{Name: "book"}).Execute()
+	updated := created.Mutate(ctx, &Order{Name: "blue book"}).Execute()
+	shipped := updated.Mutate(ctx, &Order{Name: "blue book", Shipped: true}).
+		SetEvent("shipped", 1, map[string]string{"carrier": "post"}).Execute()
+	seedHistory(t, harness, created, updated, shipped)
+
+	tests := []struct {
+		name       string
+		descending bool
+		expected   [][]string
+	}{
+		{
+			name:     "ascending pages",
+			expected: [][]string{{"order.created.v1", "order.updated.v1"}, {"order.shipped.v1"}},
+		},
+		{
+			name:       "descending pages",
+			descending: true,
+			expected:   [][]string{{"order.shipped.v1", "order.updated.v1"}, {"order.created.v1"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pages := make([][]string, 0)
+			nextToken := ""
+			for {
+				page, err := entity.FindHistory(harness.Context(), &entity.FindHistoryProps{
+					Typename:   "Order",
+					ID:         created.ID(),
+					Descending: test.descending,
+					NextToken:  nextToken,
+					Limit:      2,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				pages = append(pages, eventTypes(page.Items()))
+				if nextToken = page.NextToken(); nextToken == "" {
+					break
+				}
+			}
+			if diff := cvxtest.DiffJSON(test.expected, pages); diff != "" {
+				t.Fatalf("history pages mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFindAsOf(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ctx := harness.ExecutionContext(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "place-order.v1"}))
+
+	plainCreated := entity.Create(ctx, &Order{Name: "book"}).Execute()
+	nextVersion()
+	plainUpdated := plainCreated.Mutate(ctx, &Order{Name: "blue book"}).Execute()
+	nextVersion()
+	plainDeleted := plainUpdated.Delete(ctx).Execute()
+	plain := seedHistory(t, harness, plainCreated, plainUpdated, plainDeleted)
+
+	customCreated := entity.Create(ctx, &Order{Name: "pen"}).Execute()
+	nextVersion()
+	customShipped := customCreated.Mutate(ctx, &Order{Name: "pen", Shipped: true}).
+		SetEvent("shipped", 1, map[string]string{"carrier": "post"}).Execute()
+	nextVersion()
+	customCancelled := customShipped.Delete(ctx).
+		SetEvent("cancelled", 2, map[string]string{"reason": "lost"}).Execute()
+	custom := seedHistory(t, harness, customCreated, customShipped, customCancelled)
+
+	orderReducer := func(state map[string]interface{}, event message.Event) (map[string]interface{}, error) {
+		switch event.Type() {
+		case "order.created.v1":
+			data := make(map[string]interface{})
+			return data, event.Data(&data)
+		case "order.shipped.v1":
+			state["shipped"] = true
+			return state, nil
+		case "order.cancelled.v2":
+			return nil, nil
+		default:
+			return nil, errors.Errorf("unexpected event %s", event.Type())
+		}
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		time    time.Time
+		reducer entity.StateReducer
+		version uint64
+		status  entity.EntityStatus
+		state   *Order
+		err     error
+	}{
+		{name: "before creation", id: plainCreated.ID(), time: plain[0].Add(-time.Second)},
+		{name: "default created", id: plainCreated.ID(), time: plain[0], version: 1,
+			status: entity.EntityStatus_Alive, state: &Order{Name: "book"}},
+		{name: "default updated", id: plainCreated.ID(), time: plain[2].Add(-time.Nanosecond), version: 2,
+			status: entity.EntityStatus_Alive, state: &Order{Name: "blue book"}},
+		{name: "default deleted keeps last state", id: plainCreated.ID(), time: plain[2], version: 3,
+			status: entity.EntityStatus_Dead, state: &Order{Name: "blue book"}},
+		{name: "custom event before it happened", id: customCreated.ID(), time: custom[0], version: 1,
+			status: entity.EntityStatus_Alive, state: &Order{Name: "pen"}},
+		{name: "custom event requires reducer", id: customCreated.ID(), time: custom[1],
+			err: entity.ErrReducerRequired},
+		{name: "custom reducer", id: customCreated.ID(), time: custom[1], reducer: orderReducer, version: 2,
+			status: entity.EntityStatus_Alive, state: &Order{Name: "pen", Shipped: true}},
+		{name: "custom reducer deletion", id: customCreated.ID(), time: custom[2], reducer: orderReducer, version: 3,
+			status: entity.EntityStatus_Dead, state: &Order{Name: "pen", Shipped: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found, err := entity.FindAsOf(harness.Context(), &entity.FindAsOfProps{
+				Typename: "Order",
+				ID:       test.id,
+				Time:     test.time,
+				Reducer:  test.reducer,
+			})
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected error `%v`, found `%v`", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.state == nil {
+				if found != nil {
+					t.Fatalf("expected no entity, found version %d", found.Version())
+				}
+				return
+			}
+			if found == nil {
+				t.Fatalf("expected entity version %d, found none", test.version)
+			}
+			if found.Version() != test.version || found.Status() != test.status {
+				t.Fatalf("expected version %d %s, found %d %s", test.version, test.status, found.Version(), found.Status())
+			}
+			state := &Order{}
+			if err = found.Data(state); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cvxtest.DiffJSON(test.state, state); diff != "" {
+				t.Fatalf("state mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// getStateIndexes reads the `cvx:"index=<name>"` field tags of the state.
+// Tagged fields set the index to their formatted value, zero numbers and
+// false included; only empty strings and nil pointers remove the index.
+func getStateIndexes(state interface{}) map[string]string {
+
+	indexes := make(map[string]string)
+	rv := reflect.ValueOf(state)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return indexes
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return indexes
+	}
+
+	rt := rv.Type()
+	for idx := 0; idx < rt.NumField(); idx++ {
+		field := rt.Field(idx)
+		tag, ok := field.Tag.Lookup("cvx")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		for _, option := range strings.Split(tag, ",") {
+			option = strings.TrimSpace(option)
+			if !strings.HasPrefix(option, "index=") {
+				continue
+			}
+			name := strings.TrimPrefix(option, "index=")
+			if name == "" {
+				continue
+			}
+			indexes[name] = formatIndexValue(rv.Field(idx))
+		}
+	}
+	return indexes
+}
+
+func formatIndexValue(value reflect.Value) string {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.String {
+		return value.String()
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+func mergeIndexes(target map[string]string, source map[string]string) {
+	for name, value := range source {
+		if value == "" {
+			delete(target, name)
+		} else {
+			target[name] = value
+		}
+	}
+}
+
+func getIndexNames(indexes map[string]string) []string {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
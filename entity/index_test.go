@@ -0,0 +1,175 @@
+package entity_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+)
+
+type Ticket struct {
+	Owner    string  `json:"owner" cvx:"index=owner"`
+	Priority int     `json:"priority" cvx:"index=priority"`
+	Open     bool    `json:"open" cvx:"index=open"`
+	Queue    *string `json:"queue,omitempty" cvx:"index=queue, index=lane"`
+	Title    string  `json:"title" cvx:"index="`
+}
+
+func indexValues(item entity.Entity) map[string]string {
+	values := make(map[string]string)
+	for _, name := range item.Indexes() {
+		values[name] = item.IndexValue(name)
+	}
+	return values
+}
+
+func TestIndexes_Creation(t *testing.T) {
+
+	vip := "vip"
+	tests := []struct {
+		name     string
+		state    *Ticket
+		indexes  map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "zero values are indexed",
+			state:    &Ticket{Owner: "alice", Title: "printer"},
+			expected: map[string]string{"owner": "alice", "priority": "0", "open": "false"},
+		},
+		{
+			name:     "one field in several indexes",
+			state:    &Ticket{Owner: "alice", Priority: 2, Open: true, Queue: &vip},
+			expected: map[string]string{"owner": "alice", "priority": "2", "open": "true", "queue": "vip", "lane": "vip"},
+		},
+		{
+			name:     "empty strings are not indexed",
+			state:    &Ticket{},
+			expected: map[string]string{"priority": "0", "open": "false"},
+		},
+		{
+			name:     "explicit indexes win over tags",
+			state:    &Ticket{Owner: "alice"},
+			indexes:  map[string]string{"owner": "bob", "priority": "", "region": "eu"},
+			expected: map[string]string{"owner": "bob", "open": "false", "region": "eu"},
+		},
+	}
+
+	harness := cvxtest.NewHarness(nil)
+	ctx := harness.ExecutionContext(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "open-ticket.v1"}))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			creation := entity.Create(ctx, test.state)
+			for name, value := range test.indexes {
+				creation.SetIndex(name, value)
+			}
+			if diff := cvxtest.DiffJSON(test.expected, indexValues(creation.Execute())); diff != "" {
+				t.Fatalf("indexes mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIndexes_Removal(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	vip := "vip"
+	ticket := harness.NewEntity(&Ticket{Owner: "alice", Priority: 1, Queue: &vip})
+	if err := harness.Seed(ticket); err != nil {
+		t.Fatal(err)
+	}
+
+	mutate := func(state *Ticket, removed ...string) func(ctx context.Context, msg message.Message) (result.Result, error) {
+		return func(ctx context.Context, msg message.Message) (result.Result, error) {
+			current, err := entity.FindOne(ctx, &entity.FindOneProps{Domain: "cvxtest", Typename: "Ticket", ID: ticket.ID()})
+			if err != nil {
+				return nil, err
+			}
+			mutation := current.Mutate(ctx, state)
+			for _, name := range removed {
+				mutation.RemoveIndex(name)
+			}
+			return result.NewResult().AddEntities(mutation.Execute()), nil
+		}
+	}
+	findBy := func(index string, value string) []string {
+		page, err := entity.FindBy(harness.Context(), &entity.FindByProps{
+			Domain: "cvxtest", Typename: "Ticket", IndexName: index, IndexValue: value,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entityIDs(page.Items())
+	}
+
+	command := cvxtest.NewCommand(&cvxtest.MessageProps{Type: "update-ticket.v1"})
+	harness.Invoke(mutate(&Ticket{Owner: "alice", Queue: &vip}, "lane"), command).AssertNoError(t)
+	stored, err := harness.FindEntity("Ticket", ticket.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"owner": "alice", "priority": "0", "open": "false", "queue": "vip"}
+	if diff := cvxtest.DiffJSON(expected, indexValues(stored)); diff != "" {
+		t.Fatalf("indexes mismatch (-expected +actual):\n%s", diff)
+	}
+	if ids := findBy("lane", "vip"); len(ids) != 0 {
+		t.Fatalf("expected removed lane index, found %v", ids)
+	}
+
+	harness.Invoke(mutate(&Ticket{}), command).AssertNoError(t)
+	stored, err = harness.FindEntity("Ticket", ticket.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]string{"priority": "0", "open": "false"}
+	if diff := cvxtest.DiffJSON(expected, indexValues(stored)); diff != "" {
+		t.Fatalf("indexes mismatch (-expected +actual):\n%s", diff)
+	}
+	for index, value := range map[string]string{"owner": "alice", "queue": "vip"} {
+		if ids := findBy(index, value); len(ids) != 0 {
+			t.Fatalf("expected removed %s index, found %v", index, ids)
+		}
+	}
+	if diff := cvxtest.DiffJSON([]string{ticket.ID()}, findBy("open", "false")); diff != "" {
+		t.Fatalf("FindBy mismatch (-expected +actual):\n%s", diff)
+	}
+	item := harness.Store.Items("dyn-cvxtest-cvxtest-statestore")[0]
+	for _, key := range []string{"__owner-pk", "__queue-pk", "__lane-pk"} {
+		if _, ok := item[key]; ok {
+			t.Fatalf("expected `%s` to be removed from the stored item", key)
+		}
+	}
+}
+
+func TestIndexes_StrippedFromData(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ticket := harness.NewEntity(&Ticket{Owner: "alice", Priority: 3})
+	item, err := entity.ToDynamodb_Map(ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := item["__owner-pk"]; !ok {
+		t.Fatal("expected `__owner-pk` in the dynamodb item")
+	}
+
+	decoded, err := entity.FromDynamodb_TableMap(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"owner": "alice", "priority": "3", "open": "false"}
+	if diff := cvxtest.DiffJSON(expected, indexValues(decoded)); diff != "" {
+		t.Fatalf("indexes mismatch (-expected +actual):\n%s", diff)
+	}
+	data := make(map[string]interface{})
+	if err = decoded.Data(&data); err != nil {
+		t.Fatal(err)
+	}
+	expectedData := map[string]interface{}{"owner": "alice", "priority": 3, "open": false, "title": ""}
+	if diff := cvxtest.DiffJSON(expectedData, data); diff != "" {
+		t.Fatalf("data mismatch (-expected +actual):\n%s", diff)
+	}
+}
@@ -0,0 +1,189 @@
+package entity
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var ErrMaxItems = errors.New("maximum number of iterated entities reached")
+
+type IterateOptions struct {
+	MaxItems    uint64 `field:"optional"`
+	Concurrency int    `field:"optional"`
+}
+
+type Iterator interface {
+	Next() bool
+	Entity() Entity
+	Err() error
+	ForEach(fn func(ctx context.Context, entity Entity) error) error
+}
+
+func IterateAll(ctx context.Context, props *FindAllProps, opts *IterateOptions) Iterator {
+	pageProps := *props
+	return newIterator(ctx, opts, func(ctx context.Context, nextToken string) (EntityPage, error) {
+		pageProps.NextToken = nextToken
+		return FindAll(ctx, &pageProps)
+	}, props.NextToken)
+}
+
+func IterateBy(ctx context.Context, props *FindByProps, opts *IterateOptions) Iterator {
+	pageProps := *props
+	return newIterator(ctx, opts, func(ctx context.Context, nextToken string) (EntityPage, error) {
+		pageProps.NextToken = nextToken
+		return FindBy(ctx, &pageProps)
+	}, props.NextToken)
+}
+
+type iteratorImpl struct {
+	ctx         context.Context
+	fetch       func(ctx context.Context, nextToken string) (EntityPage, error)
+	maxItems    uint64
+	concurrency int
+	items       []Entity
+	current     Entity
+	nextToken   string
+	done        bool
+	count       uint64
+	err         error
+}
+
+func newIterator(
+	ctx context.Context,
+	opts *IterateOptions,
+	fetch func(ctx context.Context, nextToken string) (EntityPage, error),
+	nextToken string,
+) Iterator {
+
+	if opts == nil {
+		opts = &IterateOptions{}
+	}
+	return &iteratorImpl{
+		ctx:         ctx,
+		fetch:       fetch,
+		maxItems:    opts.MaxItems,
+		concurrency: opts.Concurrency,
+		nextToken:   nextToken,
+	}
+}
+
+func (it *iteratorImpl) Next() bool {
+
+	it.current = nil
+	if it.err != nil {
+		return false
+	}
+	// the maximum is checked before fetching so no page is read only to find
+	// out there are more items, a pending page counts as more items
+	if it.maxItems > 0 && it.count >= it.maxItems {
+		if len(it.items) > 0 || !it.done {
+			it.err = errors.Wrapf(ErrMaxItems, "entity iteration stopped after %d items", it.count)
+		}
+		return false
+	}
+	for len(it.items) == 0 {
+		if it.done {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = errors.Wrap(err, "entity iteration canceled")
+			return false
+		}
+		page, err := it.fetch(it.ctx, it.nextToken)
+		if err != nil {
+			it.err = errors.Wrap(err, "cannot fetch entity page")
+			return false
+		}
+		it.items = page.Items()
+		it.nextToken = page.NextToken()
+		it.done = it.nextToken == ""
+	}
+
+	if err := it.ctx.Err(); err != nil {
+		it.err = errors.Wrap(err, "entity iteration canceled")
+		return false
+	}
+
+	it.current = it.items[0]
+	it.items = it.items[1:]
+	it.count++
+	return true
+}
+
+func (it *iteratorImpl) Entity() Entity {
+	return it.current
+}
+
+func (it *iteratorImpl) Err() error {
+	return it.err
+}
+
+func (it *iteratorImpl) ForEach(fn func(ctx context.Context, entity Entity) error) error {
+
+	if it.concurrency <= 1 {
+		for it.Next() {
+			if err := fn(it.ctx, it.Entity()); err != nil {
+				return err
+			}
+		}
+		return it.Err()
+	}
+
+	// pages are fetched with the derived context so no further page is read
+	// once a worker has failed
+	parent := it.ctx
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	it.ctx = ctx
+	defer func() {
+		it.ctx = parent
+	}()
+
+	var once sync.Once
+	var failure error
+	var wg sync.WaitGroup
+	entities := make(chan Entity)
+	for worker := 0; worker < it.concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entity := range entities {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(ctx, entity); err != nil {
+					once.Do(func() {
+						failure = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for it.Next() {
+		select {
+		case entities <- it.Entity():
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(entities)
+	wg.Wait()
+
+	if failure != nil {
+		// the derived context was canceled by the failure, not by the caller,
+		// so the iterator stays usable and resumes after the dispatched entities
+		if parent.Err() == nil && errors.Is(it.err, context.Canceled) {
+			it.err = nil
+		}
+		return failure
+	}
+	if err := parent.Err(); err != nil && it.Err() == nil {
+		return errors.Wrap(err, "entity iteration canceled")
+	}
+	return it.Err()
+}
@@ -0,0 +1,155 @@
+package entity_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/pkg/errors"
+)
+
+func TestIterator_MaxItems(t *testing.T) {
+
+	store := cvxtest.NewStore()
+	queries := 0
+	harness := cvxtest.NewHarness(&cvxtest.HarnessProps{
+		Store: store,
+		DynamodbClient: &cvxtest.ClientStub{Store: store, OnQuery: func(ctx context.Context, params *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			queries++
+			return store.Query(ctx, params)
+		}},
+	})
+	ids := seedOrders(t, harness, 5)
+
+	tests := []struct {
+		name        string
+		maxItems    uint64
+		concurrency int
+		visited     int
+		queries     int
+		err         error
+	}{
+		{name: "unbounded", visited: 5, queries: 3},
+		{name: "exact maximum", maxItems: 5, visited: 5, queries: 3},
+		{name: "maximum reached", maxItems: 3, visited: 3, queries: 2, err: entity.ErrMaxItems},
+		{name: "maximum reached at page end", maxItems: 4, visited: 4, queries: 2, err: entity.ErrMaxItems},
+		{name: "maximum reached concurrently", maxItems: 3, concurrency: 2, visited: 3, queries: 2, err: entity.ErrMaxItems},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			visited := make([]string, 0)
+			queries = 0
+			err := entity.IterateAll(harness.Context(),
+				&entity.FindAllProps{Domain: "cvxtest", Typename: "Order", Limit: 2},
+				&entity.IterateOptions{MaxItems: test.maxItems, Concurrency: test.concurrency},
+			).ForEach(func(ctx context.Context, item entity.Entity) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				visited = append(visited, item.ID())
+				return nil
+			})
+
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected error `%v`, found `%v`", test.err, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if len(visited) != test.visited {
+				t.Fatalf("expected %d visited entities, found %d", test.visited, len(visited))
+			}
+			if queries != test.queries {
+				t.Fatalf("expected %d queries, found %d", test.queries, queries)
+			}
+			if test.concurrency <= 1 {
+				if diff := cvxtest.DiffJSON(ids[:test.visited], visited); diff != "" {
+					t.Fatalf("visited mismatch (-expected +actual):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestIterator_ForEachStopsOnFailure(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	seedOrders(t, harness, 10)
+	failure := errors.New("cannot process order")
+
+	// the dispatcher races the failure, so repeat to exercise both orders
+	for run := 0; run < 20; run++ {
+		// every query after the first one is held until the context of the
+		// failed entity callback is canceled
+		var queries, calls int32
+		failed := make(chan context.Context, 1)
+		gated := cvxtest.NewHarness(&cvxtest.HarnessProps{
+			Store: harness.Store,
+			DynamodbClient: &cvxtest.ClientStub{Store: harness.Store, OnQuery: func(ctx context.Context, params *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+				if atomic.AddInt32(&queries, 1) > 1 {
+					failedCtx := <-failed
+					failed <- failedCtx
+					<-failedCtx.Done()
+				}
+				return harness.Store.Query(ctx, params)
+			}},
+		})
+		iterator := entity.IterateAll(gated.Context(),
+			&entity.FindAllProps{Domain: "cvxtest", Typename: "Order", Limit: 1},
+			&entity.IterateOptions{Concurrency: 4},
+		)
+		err := iterator.ForEach(func(ctx context.Context, item entity.Entity) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				failed <- ctx
+			}
+			return failure
+		})
+
+		if !errors.Is(err, failure) {
+			t.Fatalf("expected handler failure, found %v", err)
+		}
+		// a page read while the first entity was being processed is allowed,
+		// nothing is read or dispatched after the failure
+		if queries := atomic.LoadInt32(&queries); queries > 2 {
+			t.Fatalf("expected at most 2 queries, found %d", queries)
+		}
+		if calls != 1 {
+			t.Fatalf("expected a single callback, found %d", calls)
+		}
+
+		// the failure cancels only the pass, the iterator resumes afterwards
+		if err = iterator.ForEach(func(ctx context.Context, item entity.Entity) error {
+			return nil
+		}); err != nil {
+			t.Fatalf("expected the iterator to be reusable, found %v", err)
+		}
+	}
+}
+
+func TestIterator_ForEachCanceled(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	seedOrders(t, harness, 10)
+
+	for _, concurrency := range []int{1, 4} {
+		ctx, cancel := context.WithCancel(harness.Context())
+		err := entity.IterateAll(ctx,
+			&entity.FindAllProps{Domain: "cvxtest", Typename: "Order", Limit: 2},
+			&entity.IterateOptions{Concurrency: concurrency},
+		).ForEach(func(ctx context.Context, item entity.Entity) error {
+			cancel()
+			return nil
+		})
+		cancel()
+
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("concurrency %d: expected canceled iteration, found %v", concurrency, err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/trace"
 )
 
 type Mutation interface {
@@ -14,6 +15,9 @@ type Mutation interface {
 		eventData interface{},
 	) Mutation
 
+	SetIndex(name string, value string) Mutation
+	RemoveIndex(name string) Mutation
+
 	Execute() Entity
 }
 
@@ -22,10 +26,13 @@ type mutationImpl struct {
 	Target          Entity
 	Trigger         string
 	Transaction     string
+	TraceParent     string
+	TraceState      string
 	NewEventType    string
 	NewEventVersion uint64
 	NewEventData    interface{}
 	NewEntityData   interface{}
+	NewIndexes      map[string]string
 }
 
 func newMutation(
@@ -35,12 +42,16 @@ func newMutation(
 ) Mutation {
 
 	cvx := cvxcontext.GetExecutionContenxt(ctx)
+	sc := trace.SpanContextFromContext(ctx)
 	return &mutationImpl{
 		Author:        cvx.Author,
 		Trigger:       cvx.Trigger,
 		Transaction:   cvx.Transaction,
+		TraceParent:   sc.TraceParent(),
+		TraceState:    sc.TraceState,
 		Target:        target,
 		NewEntityData: newState,
+		NewIndexes:    make(map[string]string),
 	}
 }
 
@@ -59,7 +70,29 @@ func (m *mutationImpl) SetEvent(
 	return m
 }
 
+func (m *mutationImpl) SetIndex(name string, value string) Mutation {
+	m.NewIndexes[name] = value
+	return m
+}
+
+func (m *mutationImpl) RemoveIndex(name string) Mutation {
+	m.NewIndexes[name] = ""
+	return m
+}
+
 func (m *mutationImpl) Execute() Entity {
+	indexes := make(map[string]string)
+	for _, name := range m.Target.Indexes() {
+		indexes[name] = m.Target.IndexValue(name)
+	}
+	mergeIndexes(indexes, getStateIndexes(m.NewEntityData))
+	mergeIndexes(indexes, m.NewIndexes)
+	removed := make([]string, 0)
+	for _, name := range m.Target.Indexes() {
+		if _, ok := indexes[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
 	return &entityImpl{
 		EntityID:         m.Target.ID(),
 		EntityType:       m.Target.Type(),
@@ -70,10 +103,15 @@ func (m *mutationImpl) Execute() Entity {
 		EntityUpdatedAt:  time.Now(),
 		EntityCreatedAt:  m.Target.CreatedAt(),
 		EntityCreatedBy:  m.Target.CreatedBy(),
+		EntityIndexes:    getIndexNames(indexes),
+		IndexValues:      indexes,
+		RemovedIndexes:   removed,
 		LastTransaction:  m.Transaction,
 		LastEventTrigger: m.Trigger,
 		LastEventType:    m.NewEventType,
 		LastEventVersion: m.NewEventVersion,
 		LastEventData:    m.NewEventData,
+		LastTraceParent:  m.TraceParent,
+		LastTraceState:   m.TraceState,
 	}
 }
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/cevixe/sdk/message"
+	"github.com/pkg/errors"
+)
+
+type SnapshotProps struct {
+	ID        string        `field:"required"`
+	Typename  string        `field:"required"`
+	Version   uint64        `field:"required"`
+	State     interface{}   `field:"required"`
+	CreatedAt time.Time     `field:"required"`
+	CreatedBy string        `field:"required"`
+	LastEvent message.Event `field:"required"`
+}
+
+func NewSnapshot(props *SnapshotProps) (Entity, error) {
+
+	eventType, eventVersion, err := parseEventType(props.Typename, props.LastEvent.Type())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid snapshot last event")
+	}
+
+	eventData := make(map[string]interface{})
+	if err = props.LastEvent.Data(&eventData); err != nil {
+		return nil, errors.Wrap(err, "cannot read snapshot event data")
+	}
+	traceParent, traceState := message.GetTraceContext(props.LastEvent)
+
+	return &entityImpl{
+		EntityID:         props.ID,
+		EntityType:       props.Typename,
+		EntityVersion:    props.Version,
+		EntityStatus:     EntityStatus_Alive,
+		EntityData:       props.State,
+		EntityUpdatedBy:  props.LastEvent.Author(),
+		EntityUpdatedAt:  props.LastEvent.Time(),
+		EntityCreatedBy:  props.CreatedBy,
+		EntityCreatedAt:  props.CreatedAt,
+		EntityIndexes:    make([]string, 0),
+		LastTransaction:  props.LastEvent.Transaction(),
+		LastEventTrigger: props.LastEvent.Trigger(),
+		LastEventType:    eventType,
+		LastEventVersion: eventVersion,
+		LastEventData:    eventData,
+		LastTraceParent:  traceParent,
+		LastTraceState:   traceState,
+		Snapshot:         true,
+	}, nil
+}
+
+func FindSnapshot(ctx context.Context, props *FindOneProps) (Entity, error) {
+
+	snapshot, err := getEntity(ctx, props)
+	if err != nil || !IsSnapshot(snapshot) {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func IsSnapshot(entity Entity) bool {
+	if entity == nil {
+		return false
+	}
+	return unwrapEntity(entity).Snapshot
+}
@@ -0,0 +1,151 @@
+package entity_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+)
+
+// seedOrdersWithSnapshot seeds two live orders and the snapshot of a third
+// one, the snapshot carries an owner index value so index queries see it
+func seedOrdersWithSnapshot(t *testing.T, harness *cvxtest.Harness) ([]entity.Entity, entity.Entity) {
+
+	ctx := harness.ExecutionContext(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "place-order.v1"}))
+	orders := []entity.Entity{
+		entity.Create(ctx, &Order{Name: "book"}).SetIndex("owner", "alice").Execute(),
+		entity.Create(ctx, &Order{Name: "pen"}).SetIndex("owner", "alice").Execute(),
+	}
+	aggregate := entity.Create(ctx, &Order{Name: "lamp"}).Execute()
+	event, err := aggregate.LastEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := entity.NewSnapshot(&entity.SnapshotProps{
+		ID:        aggregate.ID(),
+		Typename:  "Order",
+		Version:   aggregate.Version(),
+		State:     &Order{Name: "lamp"},
+		CreatedAt: aggregate.CreatedAt(),
+		CreatedBy: aggregate.CreatedBy(),
+		LastEvent: event,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = harness.Seed(orders[0], orders[1]); err != nil {
+		t.Fatal(err)
+	}
+	item, err := entity.ToDynamodb_Map(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item["__owner-pk"] = &types.AttributeValueMemberS{Value: "alice"}
+	for name, value := range item {
+		if _, ok := value.(*types.AttributeValueMemberNULL); ok {
+			delete(item, name)
+		}
+	}
+	if err = harness.Store.Put("dyn-cvxtest-cvxtest-statestore", item); err != nil {
+		t.Fatal(err)
+	}
+	return orders, snapshot
+}
+
+func entityIDs(entities []entity.Entity) []string {
+	ids := make([]string, 0, len(entities))
+	for _, item := range entities {
+		ids = append(ids, item.ID())
+	}
+	return ids
+}
+
+func TestSnapshots_StayOutOfQueries(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ctx := harness.Context()
+	orders, snapshot := seedOrdersWithSnapshot(t, harness)
+	expected := []string{orders[1].ID(), orders[0].ID()}
+
+	all, err := entity.FindAll(ctx, &entity.FindAllProps{Domain: "cvxtest", Typename: "Order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON(expected, entityIDs(all.Items())); diff != "" {
+		t.Fatalf("FindAll mismatch (-expected +actual):\n%s", diff)
+	}
+
+	by, err := entity.FindBy(ctx, &entity.FindByProps{Domain: "cvxtest", Typename: "Order", IndexName: "owner", IndexValue: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON(expected, entityIDs(by.Items())); diff != "" {
+		t.Fatalf("FindBy mismatch (-expected +actual):\n%s", diff)
+	}
+
+	one, err := entity.FindOne(ctx, &entity.FindOneProps{Domain: "cvxtest", Typename: "Order", ID: snapshot.ID()})
+	if err != nil || one != nil {
+		t.Fatalf("expected FindOne to ignore the snapshot, found %v and %v", one, err)
+	}
+
+	many, err := entity.FindMany(ctx, &entity.FindManyProps{Domain: "cvxtest", Typename: "Order",
+		IDs: []string{orders[0].ID(), snapshot.ID()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON([]string{orders[0].ID()}, entityIDs(many.Items())); diff != "" {
+		t.Fatalf("FindMany items mismatch (-expected +actual):\n%s", diff)
+	}
+	if diff := cvxtest.DiffJSON([]string{snapshot.ID()}, many.Missing()); diff != "" {
+		t.Fatalf("FindMany missing mismatch (-expected +actual):\n%s", diff)
+	}
+
+	iterated := make([]entity.Entity, 0)
+	err = entity.IterateAll(ctx, &entity.FindAllProps{Domain: "cvxtest", Typename: "Order", Limit: 1}, nil).
+		ForEach(func(ctx context.Context, item entity.Entity) error {
+			iterated = append(iterated, item)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON(expected, entityIDs(iterated)); diff != "" {
+		t.Fatalf("IterateAll mismatch (-expected +actual):\n%s", diff)
+	}
+
+	iterated = iterated[:0]
+	err = entity.IterateBy(ctx, &entity.FindByProps{Domain: "cvxtest", Typename: "Order", IndexName: "owner", IndexValue: "alice", Limit: 1}, nil).
+		ForEach(func(ctx context.Context, item entity.Entity) error {
+			iterated = append(iterated, item)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON(expected, entityIDs(iterated)); diff != "" {
+		t.Fatalf("IterateBy mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestFindSnapshot(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ctx := harness.Context()
+	orders, snapshot := seedOrdersWithSnapshot(t, harness)
+
+	found, err := entity.FindSnapshot(ctx, &entity.FindOneProps{Domain: "cvxtest", Typename: "Order", ID: snapshot.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || !entity.IsSnapshot(found) || found.Version() != snapshot.Version() {
+		t.Fatalf("expected snapshot %s, found %v", snapshot.ID(), found)
+	}
+
+	found, err = entity.FindSnapshot(ctx, &entity.FindOneProps{Domain: "cvxtest", Typename: "Order", ID: orders[0].ID()})
+	if err != nil || found != nil {
+		t.Fatalf("expected no snapshot for a live entity, found %v and %v", found, err)
+	}
+}
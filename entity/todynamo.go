@@ -12,7 +12,7 @@ import (
 )
 
 func ToDynamodb_Map(entity Entity) (map[string]types.AttributeValue, error) {
-	impl := entity.(*entityImpl)
+	impl := unwrapEntity(entity)
 
 	entityDataBuffer, err := json.Marshal(impl.EntityData)
 	if err != nil {
@@ -34,11 +34,26 @@ func ToDynamodb_Map(entity Entity) (map[string]types.AttributeValue, error) {
 	item["version"] = &types.AttributeValueMemberN{Value: strconv.FormatUint(impl.EntityVersion, 10)}
 	item["__status"] = &types.AttributeValueMemberS{Value: string(impl.EntityStatus)}
 	item["__space"] = &types.AttributeValueMemberS{Value: fmt.Sprintf("%s#%s", impl.EntityStatus, impl.EntityType)}
+	if impl.Snapshot {
+		// snapshots live outside the entity spaces so FindAll never lists them
+		item["__space"] = &types.AttributeValueMemberS{Value: fmt.Sprintf("snapshot#%s", impl.EntityType)}
+	}
 	item["updatedAt"] = &types.AttributeValueMemberS{Value: impl.EntityUpdatedAt.Format(time.RFC3339)}
 	item["updatedBy"] = &types.AttributeValueMemberS{Value: impl.EntityUpdatedBy}
 	item["createdAt"] = &types.AttributeValueMemberS{Value: impl.EntityCreatedAt.Format(time.RFC3339)}
 	item["createdBy"] = &types.AttributeValueMemberS{Value: impl.EntityCreatedBy}
 
+	for name, value := range impl.IndexValues {
+		item[fmt.Sprintf("__%s-pk", name)] = &types.AttributeValueMemberS{Value: value}
+	}
+	for _, name := range impl.RemovedIndexes {
+		item[fmt.Sprintf("__%s-pk", name)] = &types.AttributeValueMemberNULL{Value: true}
+	}
+
+	if impl.Snapshot {
+		item["__snapshot"] = &types.AttributeValueMemberBOOL{Value: true}
+	}
+
 	item["__transaction"] = &types.AttributeValueMemberS{Value: impl.LastTransaction}
 	if impl.LastEventTrigger != "" {
 		item["__eventtrigger"] = &types.AttributeValueMemberS{Value: impl.LastEventTrigger}
@@ -51,10 +66,20 @@ func ToDynamodb_Map(entity Entity) (map[string]types.AttributeValue, error) {
 		item["__eventtype"] = &types.AttributeValueMemberNULL{Value: true}
 	}
 	if impl.LastEventVersion > 0 {
-		item["__eventversion"] = &types.AttributeValueMemberN{Value: strconv.FormatUint(impl.EntityVersion, 10)}
+		item["__eventversion"] = &types.AttributeValueMemberN{Value: strconv.FormatUint(impl.LastEventVersion, 10)}
 	} else {
 		item["__eventversion"] = &types.AttributeValueMemberNULL{Value: true}
 	}
+	if impl.LastTraceParent != "" {
+		item["__traceparent"] = &types.AttributeValueMemberS{Value: impl.LastTraceParent}
+	} else {
+		item["__traceparent"] = &types.AttributeValueMemberNULL{Value: true}
+	}
+	if impl.LastTraceState != "" {
+		item["__tracestate"] = &types.AttributeValueMemberS{Value: impl.LastTraceState}
+	} else {
+		item["__tracestate"] = &types.AttributeValueMemberNULL{Value: true}
+	}
 	if impl.LastEventData != nil {
 
 		eventDataBuffer, err := json.Marshal(impl.LastEventData)
@@ -0,0 +1,39 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+)
+
+func TestToDynamodb_Map_PersistsLastEventVersion(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ctx := harness.ExecutionContext(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "ship-order.v1"}))
+
+	order := entity.Create(ctx, &Order{Name: "book"}).Execute()
+	order = order.Mutate(ctx, &Order{Name: "book"}).Execute()
+	order = order.Mutate(ctx, &Order{Name: "book", Shipped: true}).
+		SetEvent("shipped", 2, map[string]string{"carrier": "post"}).
+		Execute()
+
+	item, err := entity.ToDynamodb_Map(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := entity.FromDynamodb_TableMap(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := stored.LastEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Version() != 3 {
+		t.Fatalf("expected entity version 3, found %d", stored.Version())
+	}
+	if event.Type() != "order.shipped.v2" {
+		t.Fatalf("expected event type `order.shipped.v2`, found `%s`", event.Type())
+	}
+}
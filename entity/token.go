@@ -0,0 +1,258 @@
+package entity
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+const pageTokenVersion byte = 1
+
+type pageTokenMode byte
+
+const (
+	pageTokenMode_Plain     pageTokenMode = 0
+	pageTokenMode_Signed    pageTokenMode = 1
+	pageTokenMode_Encrypted pageTokenMode = 2
+)
+
+type pageToken struct {
+	Key         map[string]*tokenAttribute `json:"k"`
+	Fingerprint []byte                     `json:"f"`
+}
+
+type tokenAttribute struct {
+	S    *string `json:"S,omitempty"`
+	N    *string `json:"N,omitempty"`
+	B    []byte  `json:"B,omitempty"`
+	BOOL *bool   `json:"BOOL,omitempty"`
+}
+
+type queryFingerprint struct {
+	Table        string                     `json:"table"`
+	Index        string                     `json:"index,omitempty"`
+	KeyCondition string                     `json:"keyCondition"`
+	Filter       string                     `json:"filter,omitempty"`
+	Forward      bool                       `json:"forward"`
+	Names        map[string]string          `json:"names,omitempty"`
+	Values       map[string]*tokenAttribute `json:"values,omitempty"`
+}
+
+func encodePageToken(ctx context.Context, input *dynamodb.QueryInput, lastKey map[string]types.AttributeValue) (string, error) {
+
+	if len(lastKey) == 0 {
+		return "", nil
+	}
+
+	key, err := toTokenAttributes(lastKey)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot encode page token key")
+	}
+	fingerprint, err := getQueryFingerprint(input)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(&pageToken{Key: key, Fingerprint: fingerprint})
+	if err != nil {
+		return "", errors.Wrap(err, "cannot marshal page token")
+	}
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+	var body []byte
+	mode := getPageTokenMode(cvxini)
+	header := []byte{pageTokenVersion, byte(mode)}
+	switch mode {
+	case pageTokenMode_Encrypted:
+		gcm, err := newPageTokenCipher(cvxini.PageTokenEncryptionKey)
+		if err != nil {
+			return "", err
+		}
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+			return "", errors.Wrap(err, "cannot generate page token nonce")
+		}
+		body = gcm.Seal(nonce, nonce, payload, header)
+	case pageTokenMode_Signed:
+		body = append(payload, signPageToken(cvxini.PageTokenSigningKey, header, payload)...)
+	default:
+		body = payload
+	}
+
+	return base64.RawURLEncoding.EncodeToString(append(header, body...)), nil
+}
+
+func decodePageToken(ctx context.Context, input *dynamodb.QueryInput, token string) (map[string]types.AttributeValue, error) {
+
+	if token == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) < 2 {
+		return nil, errors.Wrap(ErrInvalidPageToken, "malformed token")
+	}
+	if raw[0] != pageTokenVersion {
+		return nil, errors.Wrapf(ErrInvalidPageToken, "unsupported token version %d", raw[0])
+	}
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+	mode := getPageTokenMode(cvxini)
+	if pageTokenMode(raw[1]) != mode {
+		return nil, errors.Wrap(ErrInvalidPageToken, "unexpected token protection")
+	}
+
+	header, body := raw[:2], raw[2:]
+	var payload []byte
+	switch mode {
+	case pageTokenMode_Encrypted:
+		gcm, err := newPageTokenCipher(cvxini.PageTokenEncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) < gcm.NonceSize() {
+			return nil, errors.Wrap(ErrInvalidPageToken, "malformed token")
+		}
+		payload, err = gcm.Open(nil, body[:gcm.NonceSize()], body[gcm.NonceSize():], header)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidPageToken, "cannot decrypt token")
+		}
+	case pageTokenMode_Signed:
+		if len(body) < sha256.Size {
+			return nil, errors.Wrap(ErrInvalidPageToken, "malformed token")
+		}
+		payload = body[:len(body)-sha256.Size]
+		signature := body[len(body)-sha256.Size:]
+		if !hmac.Equal(signature, signPageToken(cvxini.PageTokenSigningKey, header, payload)) {
+			return nil, errors.Wrap(ErrInvalidPageToken, "invalid token signature")
+		}
+	default:
+		payload = body
+	}
+
+	decoded := &pageToken{}
+	if err = json.Unmarshal(payload, decoded); err != nil {
+		return nil, errors.Wrap(ErrInvalidPageToken, "malformed token payload")
+	}
+	fingerprint, err := getQueryFingerprint(input)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(decoded.Fingerprint, fingerprint) {
+		return nil, errors.Wrap(ErrInvalidPageToken, "token issued for a different query")
+	}
+
+	key, err := fromTokenAttributes(decoded.Key)
+	if err != nil || len(key) == 0 {
+		return nil, errors.Wrap(ErrInvalidPageToken, "malformed token key")
+	}
+	return key, nil
+}
+
+func getPageTokenMode(cvxini *cvxcontext.InitContext) pageTokenMode {
+	switch {
+	case cvxini.PageTokenEncryptionKey != "":
+		return pageTokenMode_Encrypted
+	case cvxini.PageTokenSigningKey != "":
+		return pageTokenMode_Signed
+	default:
+		return pageTokenMode_Plain
+	}
+}
+
+func newPageTokenCipher(secret string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create page token cipher")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create page token cipher")
+	}
+	return gcm, nil
+}
+
+func signPageToken(secret string, header []byte, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(header)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func getQueryFingerprint(input *dynamodb.QueryInput) ([]byte, error) {
+
+	values, err := toTokenAttributes(input.ExpressionAttributeValues)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot compute query fingerprint")
+	}
+	fingerprint := &queryFingerprint{
+		Table:        aws.ToString(input.TableName),
+		Index:        aws.ToString(input.IndexName),
+		KeyCondition: aws.ToString(input.KeyConditionExpression),
+		Filter:       aws.ToString(input.FilterExpression),
+		Forward:      input.ScanIndexForward == nil || *input.ScanIndexForward,
+		Names:        input.ExpressionAttributeNames,
+		Values:       values,
+	}
+	buffer, err := json.Marshal(fingerprint)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot compute query fingerprint")
+	}
+	sum := sha256.Sum256(buffer)
+	return sum[:16], nil
+}
+
+func toTokenAttributes(item map[string]types.AttributeValue) (map[string]*tokenAttribute, error) {
+	attributes := make(map[string]*tokenAttribute, len(item))
+	for name, value := range item {
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			attributes[name] = &tokenAttribute{S: &v.Value}
+		case *types.AttributeValueMemberN:
+			attributes[name] = &tokenAttribute{N: &v.Value}
+		case *types.AttributeValueMemberB:
+			attributes[name] = &tokenAttribute{B: v.Value}
+		case *types.AttributeValueMemberBOOL:
+			attributes[name] = &tokenAttribute{BOOL: &v.Value}
+		default:
+			return nil, errors.Errorf("unsupported attribute `%s` of type %T", name, value)
+		}
+	}
+	return attributes, nil
+}
+
+func fromTokenAttributes(attributes map[string]*tokenAttribute) (map[string]types.AttributeValue, error) {
+	item := make(map[string]types.AttributeValue, len(attributes))
+	for name, value := range attributes {
+		switch {
+		case value == nil:
+			return nil, errors.Errorf("empty attribute `%s`", name)
+		case value.S != nil:
+			item[name] = &types.AttributeValueMemberS{Value: *value.S}
+		case value.N != nil:
+			item[name] = &types.AttributeValueMemberN{Value: *value.N}
+		case value.B != nil:
+			item[name] = &types.AttributeValueMemberB{Value: value.B}
+		case value.BOOL != nil:
+			item[name] = &types.AttributeValueMemberBOOL{Value: *value.BOOL}
+		default:
+			return nil, errors.Errorf("empty attribute `%s`", name)
+		}
+	}
+	return item, nil
+}
@@ -0,0 +1,114 @@
+package entity_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/pkg/errors"
+)
+
+func newTokenContext(harness *cvxtest.Harness, signingKey string, encryptionKey string) context.Context {
+	return cvxtest.NewHarness(&cvxtest.HarnessProps{
+		Store:                  harness.Store,
+		PageTokenSigningKey:    signingKey,
+		PageTokenEncryptionKey: encryptionKey,
+	}).Context()
+}
+
+func seedOrders(t *testing.T, harness *cvxtest.Harness, count int) []string {
+	ids := make([]string, 0, count)
+	for idx := 0; idx < count; idx++ {
+		order := harness.NewEntity(&Order{Name: "book"})
+		if err := harness.Seed(order); err != nil {
+			t.Fatal(err)
+		}
+		ids = append([]string{order.ID()}, ids...)
+	}
+	return ids
+}
+
+func tamperToken(t *testing.T, token string) string {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[len(raw)/2] ^= 0x01
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+type rejectedToken struct {
+	name  string
+	ctx   context.Context
+	props *entity.FindAllProps
+}
+
+func TestPageToken(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ids := seedOrders(t, harness, 5)
+
+	modes := []struct {
+		name          string
+		signingKey    string
+		encryptionKey string
+		protected     bool
+	}{
+		{name: "plain"},
+		{name: "signed", signingKey: "signing-secret", protected: true},
+		{name: "encrypted", signingKey: "signing-secret", encryptionKey: "encryption-secret", protected: true},
+	}
+
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+
+			ctx := newTokenContext(harness, mode.signingKey, mode.encryptionKey)
+			props := &entity.FindAllProps{Domain: "cvxtest", Typename: "Order", Limit: 2}
+
+			first, err := entity.FindAll(ctx, props)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token := first.NextToken()
+
+			found := entityIDs(first.Items())
+			for nextToken := token; nextToken != ""; {
+				// the limit is not part of the query fingerprint
+				page, err := entity.FindAll(ctx, &entity.FindAllProps{Domain: "cvxtest", Typename: "Order", Limit: 3, NextToken: nextToken})
+				if err != nil {
+					t.Fatal(err)
+				}
+				found = append(found, entityIDs(page.Items())...)
+				nextToken = page.NextToken()
+			}
+			if diff := cvxtest.DiffJSON(ids, found); diff != "" {
+				t.Fatalf("pages mismatch (-expected +actual):\n%s", diff)
+			}
+
+			rejected := []rejectedToken{
+				{"malformed", ctx, &entity.FindAllProps{Domain: "cvxtest", Typename: "Order", NextToken: "%%%"}},
+				{"other typename", ctx, &entity.FindAllProps{Domain: "cvxtest", Typename: "Invoice", NextToken: token}},
+				{"other order", ctx, &entity.FindAllProps{Domain: "cvxtest", Typename: "Order", Order: entity.SortOrder_Ascending, NextToken: token}},
+			}
+			if mode.protected {
+				rejected = append(rejected, []rejectedToken{
+					{"tampered", ctx, &entity.FindAllProps{Domain: "cvxtest", Typename: "Order", NextToken: tamperToken(t, token)}},
+					{"other secret", newTokenContext(harness, "other-secret", mode.encryptionKey+"-other"), &entity.FindAllProps{Domain: "cvxtest", Typename: "Order", NextToken: token}},
+					{"unprotected reader", newTokenContext(harness, "", ""), &entity.FindAllProps{Domain: "cvxtest", Typename: "Order", NextToken: token}},
+				}...)
+			} else {
+				rejected = append(rejected, rejectedToken{"protected reader", newTokenContext(harness, "signing-secret", ""), &entity.FindAllProps{Domain: "cvxtest", Typename: "Order", NextToken: token}})
+			}
+
+			for _, test := range rejected {
+				t.Run(test.name, func(t *testing.T) {
+					if _, err := entity.FindAll(test.ctx, test.props); !errors.Is(err, entity.ErrInvalidPageToken) {
+						t.Fatalf("expected invalid page token, found %v", err)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package entity
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+type Typed[T any] interface {
+	Entity
+	State() (T, error)
+}
+
+type TypedPage[T any] interface {
+	Items() []Typed[T]
+	NextToken() string
+}
+
+type TypedCreation[T any] interface {
+	SetEvent(eventType string, eventVersion uint64, eventData interface{}) TypedCreation[T]
+	SetIndex(name string, value string) TypedCreation[T]
+	Execute() Typed[T]
+}
+
+type TypedMutation[T any] interface {
+	SetEvent(eventType string, eventVersion uint64, eventData interface{}) TypedMutation[T]
+	SetIndex(name string, value string) TypedMutation[T]
+	RemoveIndex(name string) TypedMutation[T]
+	Execute() Typed[T]
+}
+
+func Typename[T any]() string {
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return rt.Name()
+}
+
+func AsTyped[T any](entity Entity) (Typed[T], error) {
+	if entity == nil {
+		return nil, nil
+	}
+	if typename := Typename[T](); entity.Type() != typename {
+		return nil, errors.Errorf("invalid entity typename `%s`, expected `%s`", entity.Type(), typename)
+	}
+	return &typedImpl[T]{Entity: entity}, nil
+}
+
+func FindOneAs[T any](ctx context.Context, props *FindOneProps) (Typed[T], error) {
+	entity, err := FindOne(ctx, &FindOneProps{
+		Domain:   props.Domain,
+		Typename: Typename[T](),
+		ID:       props.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return AsTyped[T](entity)
+}
+
+func FindAllAs[T any](ctx context.Context, props *FindAllProps) (TypedPage[T], error) {
+	typedProps := *props
+	typedProps.Typename = Typename[T]()
+	page, err := FindAll(ctx, &typedProps)
+	if err != nil {
+		return nil, err
+	}
+	return newTypedPage[T](page)
+}
+
+func FindByAs[T any](ctx context.Context, props *FindByProps) (TypedPage[T], error) {
+	typedProps := *props
+	typedProps.Typename = Typename[T]()
+	page, err := FindBy(ctx, &typedProps)
+	if err != nil {
+		return nil, err
+	}
+	return newTypedPage[T](page)
+}
+
+func CreateAs[T any](ctx context.Context, state T) TypedCreation[T] {
+	return &typedCreationImpl[T]{Creation: Create(ctx, state)}
+}
+
+func MutateAs[T any](ctx context.Context, target Entity, newState T) TypedMutation[T] {
+	mutation := target.Mutate(ctx, newState)
+	if mutation == nil {
+		return nil
+	}
+	return &typedMutationImpl[T]{Mutation: mutation}
+}
+
+type typedImpl[T any] struct {
+	Entity
+}
+
+func (t *typedImpl[T]) unwrap() Entity {
+	return t.Entity
+}
+
+func (t *typedImpl[T]) State() (T, error) {
+	var state T
+	if err := t.Entity.Data(&state); err != nil {
+		return state, err
+	}
+	return state, nil
+}
+
+type typedPageImpl[T any] struct {
+	PageItems     []Typed[T]
+	PageNextToken string
+}
+
+func newTypedPage[T any](page EntityPage) (TypedPage[T], error) {
+	items := make([]Typed[T], 0, len(page.Items()))
+	for _, item := range page.Items() {
+		typed, err := AsTyped[T](item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, typed)
+	}
+	return &typedPageImpl[T]{PageItems: items, PageNextToken: page.NextToken()}, nil
+}
+
+func (p *typedPageImpl[T]) Items() []Typed[T] {
+	return p.PageItems
+}
+
+func (p *typedPageImpl[T]) NextToken() string {
+	return p.PageNextToken
+}
+
+type typedCreationImpl[T any] struct {
+	Creation Creation
+}
+
+func (c *typedCreationImpl[T]) SetEvent(eventType string, eventVersion uint64, eventData interface{}) TypedCreation[T] {
+	c.Creation.SetEvent(eventType, eventVersion, eventData)
+	return c
+}
+
+func (c *typedCreationImpl[T]) SetIndex(name string, value string) TypedCreation[T] {
+	c.Creation.SetIndex(name, value)
+	return c
+}
+
+func (c *typedCreationImpl[T]) Execute() Typed[T] {
+	return &typedImpl[T]{Entity: c.Creation.Execute()}
+}
+
+type typedMutationImpl[T any] struct {
+	Mutation Mutation
+}
+
+func (m *typedMutationImpl[T]) SetEvent(eventType string, eventVersion uint64, eventData interface{}) TypedMutation[T] {
+	m.Mutation.SetEvent(eventType, eventVersion, eventData)
+	return m
+}
+
+func (m *typedMutationImpl[T]) SetIndex(name string, value string) TypedMutation[T] {
+	m.Mutation.SetIndex(name, value)
+	return m
+}
+
+func (m *typedMutationImpl[T]) RemoveIndex(name string) TypedMutation[T] {
+	m.Mutation.RemoveIndex(name)
+	return m
+}
+
+func (m *typedMutationImpl[T]) Execute() Typed[T] {
+	return &typedImpl[T]{Entity: m.Mutation.Execute()}
+}
+
+func unwrapEntity(entity Entity) *entityImpl {
+	for {
+		typed, ok := entity.(interface{ unwrap() Entity })
+		if !ok {
+			return entity.(*entityImpl)
+		}
+		entity = typed.unwrap()
+	}
+}
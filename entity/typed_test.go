@@ -0,0 +1,171 @@
+package entity_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+)
+
+func TestTyped_RoundTrip(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	var created entity.Typed[Order]
+	create := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		created = entity.CreateAs(ctx, Order{Name: "book"}).
+			SetEvent("placed", 1, nil).
+			SetIndex("owner", "alice").
+			Execute()
+		return result.NewResult().AddEntities(created), nil
+	}
+	harness.Invoke(create, cvxtest.NewCommand(&cvxtest.MessageProps{Type: "place-order.v1"})).AssertNoError(t)
+
+	found, err := entity.FindOneAs[Order](harness.Context(), &entity.FindOneProps{Domain: "cvxtest", ID: created.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := found.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON(Order{Name: "book"}, state); diff != "" {
+		t.Fatalf("state mismatch (-expected +actual):\n%s", diff)
+	}
+	event, err := found.LastEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Version() != 1 || found.IndexValue("owner") != "alice" || event.Type() != "order.placed.v1" {
+		t.Fatalf("unexpected entity version %d, owner `%s` and event `%s`", found.Version(), found.IndexValue("owner"), event.Type())
+	}
+
+	ship := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		current, err := entity.FindOneAs[*Order](ctx, &entity.FindOneProps{Domain: "cvxtest", ID: created.ID()})
+		if err != nil {
+			return nil, err
+		}
+		order, err := current.State()
+		if err != nil {
+			return nil, err
+		}
+		order.Shipped = true
+		return result.NewResult().AddEntities(entity.MutateAs(ctx, current, order).
+			SetEvent("shipped", 2, nil).
+			RemoveIndex("owner").
+			Execute()), nil
+	}
+	harness.Invoke(ship, cvxtest.NewCommand(&cvxtest.MessageProps{Type: "ship-order.v1"})).AssertNoError(t)
+
+	shipped, err := entity.FindOneAs[*Order](harness.Context(), &entity.FindOneProps{Domain: "cvxtest", ID: created.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := shipped.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON(&Order{Name: "book", Shipped: true}, order); diff != "" {
+		t.Fatalf("state mismatch (-expected +actual):\n%s", diff)
+	}
+	if event, err = shipped.LastEvent(); err != nil {
+		t.Fatal(err)
+	}
+	if shipped.Version() != 2 || len(shipped.Indexes()) != 0 || event.Type() != "order.shipped.v2" {
+		t.Fatalf("unexpected entity version %d, indexes %v and event `%s`", shipped.Version(), shipped.Indexes(), event.Type())
+	}
+}
+
+func TestTyped_Find(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ctx := harness.ExecutionContext(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "seed.v1"}))
+	book := entity.CreateAs(ctx, &Order{Name: "book"}).SetIndex("owner", "alice").Execute()
+	pen := entity.CreateAs(ctx, &Order{Name: "pen"}).SetIndex("owner", "alice").Execute()
+	invoice := entity.CreateAs(ctx, &Invoice{Number: "F-1"}).SetIndex("owner", "alice").Execute()
+	if err := harness.Seed(book, pen, invoice); err != nil {
+		t.Fatal(err)
+	}
+	states := func(page entity.TypedPage[Order]) []Order {
+		orders := make([]Order, 0, len(page.Items()))
+		for _, item := range page.Items() {
+			state, err := item.State()
+			if err != nil {
+				t.Fatal(err)
+			}
+			orders = append(orders, state)
+		}
+		return orders
+	}
+	expected := []Order{{Name: "pen"}, {Name: "book"}}
+
+	all, err := entity.FindAllAs[Order](harness.Context(), &entity.FindAllProps{Domain: "cvxtest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON(expected, states(all)); diff != "" {
+		t.Fatalf("FindAllAs mismatch (-expected +actual):\n%s", diff)
+	}
+
+	by, err := entity.FindByAs[Order](harness.Context(), &entity.FindByProps{Domain: "cvxtest", IndexName: "owner", IndexValue: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON(expected, states(by)); diff != "" {
+		t.Fatalf("FindByAs mismatch (-expected +actual):\n%s", diff)
+	}
+
+	missing, err := entity.FindOneAs[Order](harness.Context(), &entity.FindOneProps{Domain: "cvxtest", ID: "missing"})
+	if err != nil || missing != nil {
+		t.Fatalf("expected nil for a missing entity, found %v, %v", missing, err)
+	}
+	if _, err = entity.FindOneAs[Order](harness.Context(), &entity.FindOneProps{Domain: "cvxtest", ID: invoice.ID()}); err == nil {
+		t.Fatal("expected typename mismatch error for an entity of another type")
+	}
+}
+
+func TestTyped_AsTyped(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	order := harness.NewEntity(&Order{Name: "book"})
+
+	if _, err := entity.AsTyped[Invoice](order); err == nil {
+		t.Fatal("expected typename mismatch error")
+	}
+	if typed, err := entity.AsTyped[Order](nil); err != nil || typed != nil {
+		t.Fatalf("expected nil for a nil entity, found %v, %v", typed, err)
+	}
+	if name := entity.Typename[**Order](); name != "Order" {
+		t.Fatalf("expected typename `Order`, found `%s`", name)
+	}
+
+	// typed wrappers are unwrapped, even nested, before being persisted
+	typed, err := entity.AsTyped[Order](order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested, err := entity.AsTyped[*Order](typed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := entity.ToDynamodb_Map(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := entity.ToDynamodb_Map(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON(expected, actual); diff != "" {
+		t.Fatalf("dynamodb map mismatch (-expected +actual):\n%s", diff)
+	}
+	if err = harness.Seed(nested); err != nil {
+		t.Fatal(err)
+	}
+	found, err := entity.FindOneAs[Order](harness.Context(), &entity.FindOneProps{Domain: "cvxtest", ID: order.ID()})
+	if err != nil || found == nil {
+		t.Fatalf("expected seeded typed entity, found %v, %v", found, err)
+	}
+}
@@ -3,8 +3,11 @@ package handler
 import (
 	"context"
 
+	"github.com/cevixe/sdk/entity"
 	"github.com/cevixe/sdk/message"
 	"github.com/cevixe/sdk/result"
 )
 
 type Handler func(ctx context.Context, msg message.Message) (result.Result, error)
+
+type EntityChangeHandler func(ctx context.Context, oldEntity entity.Entity, newEntity entity.Entity) (result.Result, error)
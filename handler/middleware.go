@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/pkg/errors"
+)
+
+type Middleware func(Handler) Handler
+
+func Chain(hdl Handler, middlewares ...Middleware) Handler {
+	for idx := len(middlewares) - 1; idx >= 0; idx-- {
+		hdl = middlewares[idx](hdl)
+	}
+	return hdl
+}
+
+func Recovery() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg message.Message) (res result.Result, err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					cvxcontext.GetLogger(ctx).Error("message handler panic",
+						"panic", fmt.Sprint(recovered),
+						"stack", string(debug.Stack()))
+					res = nil
+					err = errors.Errorf("message handler panic: %v", recovered)
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
+
+func Deadline(margin time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg message.Message) (result.Result, error) {
+
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return next(ctx, msg)
+			}
+
+			ctx, cancel := context.WithDeadline(ctx, deadline.Add(-margin))
+			defer cancel()
+
+			// the handler runs on the caller goroutine so nothing it started can
+			// outlive a reported failure; a late result is discarded unwritten
+			res, err := next(ctx, msg)
+			if ctxErr := ctx.Err(); errors.Is(ctxErr, context.DeadlineExceeded) {
+				if err != nil {
+					return nil, errors.Wrapf(ctxErr, "message handler deadline exceeded: %v", err)
+				}
+				return nil, errors.Wrap(ctxErr, "message handler deadline exceeded")
+			}
+			return res, err
+		}
+	}
+}
+
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg message.Message) (result.Result, error) {
+
+			log := cvxcontext.GetLogger(ctx).With(
+				"source", msg.Source(),
+				"id", msg.ID(),
+				"kind", msg.Kind(),
+			)
+			log.Debug("message handler started")
+
+			start := time.Now()
+			res, err := next(ctx, msg)
+			duration := time.Since(start)
+
+			switch {
+			case err != nil:
+				log.Error("message handler finished", "outcome", "error", "duration", duration, "error", err)
+			case res == nil:
+				log.Info("message handler finished", "outcome", "nil", "duration", duration)
+			default:
+				log.Info("message handler finished", "outcome", "result", "duration", duration,
+					"entities", len(res.GetEntities()), "commands", len(res.GetCommands()))
+			}
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package handler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/handler"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/pkg/errors"
+)
+
+func TestDeadline(t *testing.T) {
+
+	msg := cvxtest.NewCommand(&cvxtest.MessageProps{Type: "do-something.v1"})
+	var finished int32
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		handler handler.Handler
+		result  bool
+		err     error
+	}{
+		{
+			name:    "handler finishing in time returns its result",
+			timeout: time.Second,
+			handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+				return result.NewResult(), nil
+			},
+			result: true,
+		},
+		{
+			name:    "handler honoring the deadline is awaited",
+			timeout: 150 * time.Millisecond,
+			handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+				<-ctx.Done()
+				time.Sleep(20 * time.Millisecond)
+				atomic.StoreInt32(&finished, 1)
+				return nil, ctx.Err()
+			},
+			err: context.DeadlineExceeded,
+		},
+		{
+			name:    "late result is discarded",
+			timeout: 150 * time.Millisecond,
+			handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+				time.Sleep(100 * time.Millisecond)
+				atomic.StoreInt32(&finished, 1)
+				return result.NewResult(), nil
+			},
+			err: context.DeadlineExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			atomic.StoreInt32(&finished, 0)
+			ctx, cancel := context.WithTimeout(context.Background(), test.timeout)
+			defer cancel()
+
+			hdl := handler.Chain(test.handler, handler.Deadline(100*time.Millisecond))
+			res, err := hdl(ctx, msg)
+
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected error `%v`, found `%v`", test.err, err)
+				}
+				if res != nil {
+					t.Fatalf("expected no result after deadline")
+				}
+				if atomic.LoadInt32(&finished) != 1 {
+					t.Fatalf("deadline returned before the handler finished")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (res != nil) != test.result {
+				t.Fatalf("expected result %v, found %v", test.result, res != nil)
+			}
+		})
+	}
+}
+
+func TestDeadline_WithoutContextDeadline(t *testing.T) {
+
+	msg := cvxtest.NewCommand(&cvxtest.MessageProps{Type: "do-something.v1"})
+	hdl := handler.Deadline(time.Second)(func(ctx context.Context, msg message.Message) (result.Result, error) {
+		if _, ok := ctx.Deadline(); ok {
+			t.Fatalf("unexpected deadline in handler context")
+		}
+		return result.NewResult(), nil
+	})
+
+	res, err := hdl(context.Background(), msg)
+	if err != nil || res == nil {
+		t.Fatalf("expected result without error, found %v and %v", res, err)
+	}
+}
+
+func TestChain_RecoveryInsideDeadline(t *testing.T) {
+
+	msg := cvxtest.NewCommand(&cvxtest.MessageProps{Type: "do-something.v1"})
+	order := make([]string, 0)
+	trace := func(name string) handler.Middleware {
+		return func(next handler.Handler) handler.Handler {
+			return func(ctx context.Context, msg message.Message) (result.Result, error) {
+				order = append(order, name)
+				return next(ctx, msg)
+			}
+		}
+	}
+
+	hdl := handler.Chain(
+		func(ctx context.Context, msg message.Message) (result.Result, error) { panic("boom") },
+		trace("outer"), handler.Deadline(time.Millisecond), trace("inner"), handler.Recovery(),
+	)
+
+	ctx, cancel := context.WithTimeout(cvxtest.NewHarness(nil).Context(), time.Second)
+	defer cancel()
+	if _, err := hdl(ctx, msg); err == nil {
+		t.Fatalf("expected recovered panic error")
+	}
+	if diff := cvxtest.DiffJSON([]string{"outer", "inner"}, order); diff != "" {
+		t.Fatalf("middleware order mismatch (-expected +actual):\n%s", diff)
+	}
+}
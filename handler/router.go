@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/pkg/errors"
+)
+
+type UnhandledPolicy string
+
+const (
+	UnhandledPolicy_Ignore     UnhandledPolicy = "ignore"
+	UnhandledPolicy_Error      UnhandledPolicy = "error"
+	UnhandledPolicy_DeadLetter UnhandledPolicy = "dead-letter"
+)
+
+var ErrUnhandledMessage = errors.New("unhandled message")
+
+type Route struct {
+	Kind       message.MessageKind `field:"optional"`
+	Pattern    string              `field:"optional"`
+	MinVersion uint64              `field:"optional"`
+	MaxVersion uint64              `field:"optional"`
+	Handler    Handler             `field:"required"`
+}
+
+// Router dispatches messages to handlers by type. Handler is a func type and
+// cannot carry the route table, so the Handle method value is the router's
+// Handler, e.g. runtime.Start(router.Handle)
+type Router interface {
+	On(pattern string, hdl Handler) Router
+	OnKind(kind message.MessageKind, hdl Handler) Router
+	OnRoute(route *Route) Router
+	SetUnhandledPolicy(policy UnhandledPolicy) Router
+	SetDeadLetterHandler(hdl Handler) Router
+	Handle(ctx context.Context, msg message.Message) (result.Result, error)
+}
+
+func NewRouter() Router {
+	return &routerImpl{
+		exact:  make(map[string]Handler),
+		routes: make([]*Route, 0),
+		policy: UnhandledPolicy_Ignore,
+	}
+}
+
+type routerImpl struct {
+	exact      map[string]Handler
+	routes     []*Route
+	policy     UnhandledPolicy
+	deadLetter Handler
+}
+
+func (r *routerImpl) On(pattern string, hdl Handler) Router {
+	if !strings.ContainsAny(pattern, "*?[") {
+		r.exact[pattern] = hdl
+		return r
+	}
+	return r.OnRoute(&Route{Pattern: pattern, Handler: hdl})
+}
+
+func (r *routerImpl) OnKind(kind message.MessageKind, hdl Handler) Router {
+	return r.OnRoute(&Route{Kind: kind, Handler: hdl})
+}
+
+func (r *routerImpl) OnRoute(route *Route) Router {
+	r.routes = append(r.routes, route)
+	return r
+}
+
+func (r *routerImpl) SetUnhandledPolicy(policy UnhandledPolicy) Router {
+	r.policy = policy
+	return r
+}
+
+func (r *routerImpl) SetDeadLetterHandler(hdl Handler) Router {
+	r.deadLetter = hdl
+	return r
+}
+
+func (r *routerImpl) Handle(ctx context.Context, msg message.Message) (result.Result, error) {
+
+	if hdl, ok := r.exact[msg.Type()]; ok {
+		return hdl(ctx, msg)
+	}
+
+	for _, route := range r.routes {
+		if MatchRoute(route, msg) {
+			return route.Handler(ctx, msg)
+		}
+	}
+
+	switch r.policy {
+	case UnhandledPolicy_Error:
+		return nil, errors.Wrapf(ErrUnhandledMessage, "no route found for message type `%s`", msg.Type())
+	case UnhandledPolicy_DeadLetter:
+		if r.deadLetter == nil {
+			return nil, errors.Wrap(ErrUnhandledMessage, "dead letter handler not found")
+		}
+		return r.deadLetter(ctx, msg)
+	default:
+		return nil, nil
+	}
+}
+
+var versionedTypeRegexp = regexp.MustCompile(`^(.+)\.v(\d+)$`)
+
+func ParseMessageType(messageType string) (string, uint64) {
+	match := versionedTypeRegexp.FindStringSubmatch(messageType)
+	if match == nil {
+		return messageType, 0
+	}
+	version, err := strconv.ParseUint(match[2], 10, 64)
+	if err != nil {
+		return messageType, 0
+	}
+	return match[1], version
+}
+
+func MatchRoute(route *Route, msg message.Message) bool {
+
+	if route.Kind != "" && route.Kind != msg.Kind() {
+		return false
+	}
+
+	name, version := ParseMessageType(msg.Type())
+	if route.MinVersion > 0 && version < route.MinVersion {
+		return false
+	}
+	if route.MaxVersion > 0 && version > route.MaxVersion {
+		return false
+	}
+
+	if route.Pattern == "" {
+		return true
+	}
+	if _, patternVersion := ParseMessageType(route.Pattern); patternVersion > 0 ||
+		strings.HasSuffix(route.Pattern, ".v*") {
+		return MatchType(route.Pattern, msg.Type())
+	}
+	return MatchType(route.Pattern, name)
+}
+
+func MatchType(pattern string, messageType string) bool {
+	patternSegments := strings.Split(pattern, ".")
+	typeSegments := strings.Split(messageType, ".")
+	if len(patternSegments) != len(typeSegments) {
+		return false
+	}
+	for idx, segment := range patternSegments {
+		matched, err := path.Match(segment, typeSegments[idx])
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package handler_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/handler"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/pkg/errors"
+)
+
+func TestRouter_Routes(t *testing.T) {
+
+	var routed string
+	named := func(name string) handler.Handler {
+		return func(ctx context.Context, msg message.Message) (result.Result, error) {
+			routed = name
+			return result.NewResult(), nil
+		}
+	}
+
+	router := handler.NewRouter().
+		On("order.*.v1", named("glob")).
+		On("order.created.v1", named("exact")).
+		On("invoice.paid.v*", named("any version")).
+		OnRoute(&handler.Route{Pattern: "invoice.issued", MinVersion: 2, MaxVersion: 3, Handler: named("version range")}).
+		OnRoute(&handler.Route{Pattern: "invoice.*", Handler: named("first match")}).
+		OnRoute(&handler.Route{Pattern: "invoice.*", Handler: named("second match")}).
+		OnKind(message.MessageKind_Event, named("events"))
+
+	tests := []struct {
+		name     string
+		kind     message.MessageKind
+		typ      string
+		expected string
+	}{
+		{name: "exact wins over earlier glob", typ: "order.created.v1", expected: "exact"},
+		{name: "glob", typ: "order.shipped.v1", expected: "glob"},
+		{name: "glob keeps the version", typ: "order.shipped.v2", expected: ""},
+		{name: "version suffix", typ: "invoice.paid.v7", expected: "any version"},
+		{name: "version suffix needs a version", typ: "invoice.paid", expected: "first match"},
+		{name: "version range lower bound", typ: "invoice.issued.v2", expected: "version range"},
+		{name: "version range upper bound", typ: "invoice.issued.v3", expected: "version range"},
+		{name: "outside version range", typ: "invoice.issued.v4", expected: "first match"},
+		{name: "first matching route wins", typ: "invoice.voided.v1", expected: "first match"},
+		{name: "kind route", kind: message.MessageKind_Event, typ: "customer.created.v1", expected: "events"},
+		{name: "kind route ignores other kinds", typ: "customer.created.v1", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			routed = ""
+			var msg message.Message
+			if test.kind == message.MessageKind_Event {
+				msg = cvxtest.NewEvent(&cvxtest.MessageProps{Type: test.typ})
+			} else {
+				msg = cvxtest.NewCommand(&cvxtest.MessageProps{Type: test.typ})
+			}
+			if _, err := router.Handle(context.Background(), msg); err != nil {
+				t.Fatal(err)
+			}
+			if routed != test.expected {
+				t.Fatalf("expected route `%s`, found `%s`", test.expected, routed)
+			}
+		})
+	}
+}
+
+func TestRouter_UnhandledPolicy(t *testing.T) {
+
+	var deadLetters int
+	deadLetter := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		deadLetters++
+		return result.NewResult(), nil
+	}
+
+	tests := []struct {
+		name        string
+		policy      handler.UnhandledPolicy
+		deadLetter  handler.Handler
+		result      bool
+		deadLetters int
+		err         error
+	}{
+		{name: "ignore by default"},
+		{name: "ignore", policy: handler.UnhandledPolicy_Ignore},
+		{name: "error", policy: handler.UnhandledPolicy_Error, err: handler.ErrUnhandledMessage},
+		{name: "dead letter", policy: handler.UnhandledPolicy_DeadLetter, deadLetter: deadLetter, result: true, deadLetters: 1},
+		{name: "dead letter without handler", policy: handler.UnhandledPolicy_DeadLetter, err: handler.ErrUnhandledMessage},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deadLetters = 0
+			router := handler.NewRouter().On("order.created.v1", deadLetter)
+			if test.policy != "" {
+				router.SetUnhandledPolicy(test.policy)
+			}
+			if test.deadLetter != nil {
+				router.SetDeadLetterHandler(test.deadLetter)
+			}
+
+			// the Handle method value is the router's handler.Handler
+			var hdl handler.Handler = router.Handle
+			res, err := hdl(context.Background(), cvxtest.NewCommand(&cvxtest.MessageProps{Type: "order.deleted.v1"}))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, found %v", test.err, err)
+			}
+			if (res != nil) != test.result {
+				t.Fatalf("expected result %t, found %v", test.result, res)
+			}
+			if deadLetters != test.deadLetters {
+				t.Fatalf("expected %d dead letters, found %d", test.deadLetters, deadLetters)
+			}
+		})
+	}
+}
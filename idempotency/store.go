@@ -0,0 +1,115 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/jsii-runtime-go"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/trace"
+	"github.com/pkg/errors"
+)
+
+type recordContextKey string
+
+const recordRequestedKey recordContextKey = "cvxdedup"
+
+// WithRecord marks the writes under ctx as the final write of the message,
+// the only one that stores its deduplication record
+func WithRecord(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recordRequestedKey, true)
+}
+
+func IsEnabled(ctx context.Context) bool {
+	cvxexe, ok := ctx.Value(cvxcontext.CevixeExecutionContextKey).(*cvxcontext.ExecutionContext)
+	return ok && cvxexe.DeduplicationTTL > 0
+}
+
+func IsProcessed(ctx context.Context) (bool, error) {
+
+	if !IsEnabled(ctx) {
+		return false, nil
+	}
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+	cvxexe := cvxcontext.GetExecutionContenxt(ctx)
+
+	input := &dynamodb.GetItemInput{
+		TableName: jsii.String(tableName(cvxini)),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: recordID(cvxini, cvxexe)},
+		},
+		ConsistentRead: jsii.Bool(true),
+	}
+
+	ctx, span := trace.Start(ctx, "dynamodb.GetItem", "table", *input.TableName)
+	output, err := cvxini.DynamodbClient.GetItem(ctx, input)
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot get dynamodb deduplication record")
+	}
+
+	if output.Item == nil {
+		return false, nil
+	}
+
+	expiresAt, ok := output.Item["expiresAt"].(*types.AttributeValueMemberN)
+	if !ok {
+		return true, nil
+	}
+	expiration, err := strconv.ParseInt(expiresAt.Value, 10, 64)
+	if err != nil {
+		return false, errors.Wrap(err, "invalid deduplication record expiration")
+	}
+
+	// expired records may still be there until dynamodb ttl removes them
+	return time.Now().Unix() < expiration, nil
+}
+
+func GenerateTransactPut(ctx context.Context) *types.TransactWriteItem {
+
+	if requested, _ := ctx.Value(recordRequestedKey).(bool); !requested || !IsEnabled(ctx) {
+		return nil
+	}
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+	cvxexe := cvxcontext.GetExecutionContenxt(ctx)
+
+	now := time.Now()
+	expiresAt := now.Add(cvxexe.DeduplicationTTL).Unix()
+
+	return &types.TransactWriteItem{
+		Put: &types.Put{
+			TableName: jsii.String(tableName(cvxini)),
+			Item: map[string]types.AttributeValue{
+				"id":          &types.AttributeValueMemberS{Value: recordID(cvxini, cvxexe)},
+				"handler":     &types.AttributeValueMemberS{Value: cvxini.HandlerName},
+				"trigger":     &types.AttributeValueMemberS{Value: cvxexe.Trigger},
+				"transaction": &types.AttributeValueMemberS{Value: cvxexe.Transaction},
+				"processedAt": &types.AttributeValueMemberS{Value: now.Format(time.RFC3339)},
+				"expiresAt":   &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt, 10)},
+			},
+			ConditionExpression: jsii.String("attribute_not_exists(#id) OR #expiresAt < :now"),
+			ExpressionAttributeNames: map[string]string{
+				"#id":        "id",
+				"#expiresAt": "expiresAt",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+			},
+		},
+	}
+}
+
+func tableName(cvxini *cvxcontext.InitContext) string {
+	return fmt.Sprintf("dyn-%s-%s-dedupstore", cvxini.AppName, cvxini.DomainName)
+}
+
+func recordID(cvxini *cvxcontext.InitContext, cvxexe *cvxcontext.ExecutionContext) string {
+	return fmt.Sprintf("%s#%s", cvxini.HandlerName, cvxexe.Trigger)
+}
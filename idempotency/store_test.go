@@ -0,0 +1,89 @@
+package idempotency_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/idempotency"
+)
+
+const dedupstore = "dyn-cvxtest-cvxtest-dedupstore"
+
+func newDedupContext(harness *cvxtest.Harness, ttl time.Duration) context.Context {
+	return context.WithValue(harness.Context(), cvxcontext.CevixeExecutionContextKey,
+		&cvxcontext.ExecutionContext{
+			Author:           "cvxtest",
+			Trigger:          "/cvxtest/01H0000000000000000000001",
+			Transaction:      "01H0000000000000000000002",
+			MessageType:      "place-order.v1",
+			DeduplicationTTL: ttl,
+		})
+}
+
+func putRecord(t *testing.T, harness *cvxtest.Harness, expiresAt time.Time) {
+	t.Helper()
+	err := harness.Store.Put(dedupstore, map[string]types.AttributeValue{
+		"id":        &types.AttributeValueMemberS{Value: "cvxtest#/cvxtest/01H0000000000000000000001"},
+		"expiresAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsProcessed(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		expiresAt time.Time
+		expected  bool
+	}{
+		{name: "missing record", ttl: time.Hour},
+		{name: "live record", ttl: time.Hour, expiresAt: time.Now().Add(time.Hour), expected: true},
+		{name: "expired record", ttl: time.Hour, expiresAt: time.Now().Add(-time.Minute)},
+		{name: "disabled", expiresAt: time.Now().Add(time.Hour)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			harness := cvxtest.NewHarness(nil)
+			if !test.expiresAt.IsZero() {
+				putRecord(t, harness, test.expiresAt)
+			}
+			processed, err := idempotency.IsProcessed(newDedupContext(harness, test.ttl))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if processed != test.expected {
+				t.Fatalf("expected processed %t, found %t", test.expected, processed)
+			}
+		})
+	}
+}
+
+func TestGenerateTransactPut(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+
+	if put := idempotency.GenerateTransactPut(newDedupContext(harness, time.Hour)); put != nil {
+		t.Fatal("expected no record outside the final write")
+	}
+	if put := idempotency.GenerateTransactPut(idempotency.WithRecord(newDedupContext(harness, 0))); put != nil {
+		t.Fatal("expected no record when deduplication is disabled")
+	}
+
+	put := idempotency.GenerateTransactPut(idempotency.WithRecord(newDedupContext(harness, time.Hour)))
+	if put == nil || put.Put == nil {
+		t.Fatal("expected deduplication record put")
+	}
+	id, ok := put.Put.Item["id"].(*types.AttributeValueMemberS)
+	if !ok || id.Value != "cvxtest#/cvxtest/01H0000000000000000000001" {
+		t.Fatalf("unexpected deduplication record id %v", put.Put.Item["id"])
+	}
+}
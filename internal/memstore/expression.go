@@ -0,0 +1,553 @@
+package memstore
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+)
+
+type tokenKind int
+
+const (
+	tokenKind_EOF tokenKind = iota
+	tokenKind_Name
+	tokenKind_Value
+	tokenKind_Ident
+	tokenKind_Operator
+	tokenKind_LParen
+	tokenKind_RParen
+	tokenKind_Comma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(expression string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(expression)
+	for idx := 0; idx < len(runes); {
+		char := runes[idx]
+		switch {
+		case unicode.IsSpace(char):
+			idx++
+		case char == '(':
+			tokens = append(tokens, token{kind: tokenKind_LParen, text: "("})
+			idx++
+		case char == ')':
+			tokens = append(tokens, token{kind: tokenKind_RParen, text: ")"})
+			idx++
+		case char == ',':
+			tokens = append(tokens, token{kind: tokenKind_Comma, text: ","})
+			idx++
+		case strings.ContainsRune("=<>+-", char):
+			end := idx + 1
+			if end < len(runes) && (runes[end] == '=' || (char == '<' && runes[end] == '>')) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenKind_Operator, text: string(runes[idx:end])})
+			idx = end
+		case char == '#' || char == ':' || isIdentRune(char):
+			end := idx + 1
+			for end < len(runes) && isIdentRune(runes[end]) {
+				end++
+			}
+			text := string(runes[idx:end])
+			kind := tokenKind_Ident
+			if char == '#' {
+				kind = tokenKind_Name
+			} else if char == ':' {
+				kind = tokenKind_Value
+			}
+			tokens = append(tokens, token{kind: kind, text: text})
+			idx = end
+		default:
+			return nil, errors.Errorf("unexpected character `%c` in expression", char)
+		}
+	}
+	return append(tokens, token{kind: tokenKind_EOF}), nil
+}
+
+func isIdentRune(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_' || char == '.'
+}
+
+type expressionContext struct {
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+func (c *expressionContext) attributeName(tok token) (string, error) {
+	switch tok.kind {
+	case tokenKind_Name:
+		name, ok := c.names[tok.text]
+		if !ok {
+			return "", errors.Errorf("expression attribute name `%s` not defined", tok.text)
+		}
+		return name, nil
+	case tokenKind_Ident:
+		return tok.text, nil
+	default:
+		return "", errors.Errorf("expected attribute name, found `%s`", tok.text)
+	}
+}
+
+type parser struct {
+	tokens  []token
+	pos     int
+	context *expressionContext
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenKind_EOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == tokenKind_Ident && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	tok := p.next()
+	if tok.kind != kind {
+		return errors.Errorf("expected `%s`, found `%s`", text, tok.text)
+	}
+	return nil
+}
+
+type condition func(item map[string]types.AttributeValue) bool
+
+type operand func(item map[string]types.AttributeValue) types.AttributeValue
+
+func compileCondition(expression *string, ctx *expressionContext) (condition, error) {
+	if expression == nil || strings.TrimSpace(*expression) == "" {
+		return func(map[string]types.AttributeValue) bool { return true }, nil
+	}
+	tokens, err := tokenize(*expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, context: ctx}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid expression `%s`", *expression)
+	}
+	if p.peek().kind != tokenKind_EOF {
+		return nil, errors.Errorf("invalid expression `%s`: unexpected `%s`", *expression, p.peek().text)
+	}
+	return cond, nil
+}
+
+func (p *parser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(item map[string]types.AttributeValue) bool { return l(item) || r(item) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(item map[string]types.AttributeValue) bool { return l(item) && r(item) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (condition, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(item map[string]types.AttributeValue) bool { return !inner(item) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (condition, error) {
+
+	tok := p.peek()
+	if tok.kind == tokenKind_LParen {
+		p.next()
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return cond, p.expect(tokenKind_RParen, ")")
+	}
+
+	if tok.kind == tokenKind_Ident && p.tokens[p.pos+1].kind == tokenKind_LParen {
+		return p.parseFunction()
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.isKeyword("BETWEEN") {
+		p.next()
+		lower, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isKeyword("AND") {
+			return nil, errors.New("expected `AND` in between condition")
+		}
+		p.next()
+		upper, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return func(item map[string]types.AttributeValue) bool {
+			value := left(item)
+			low, okLow := compareValues(value, lower(item))
+			high, okHigh := compareValues(value, upper(item))
+			return okLow && okHigh && low >= 0 && high <= 0
+		}, nil
+	}
+
+	if p.isKeyword("IN") {
+		p.next()
+		if err := p.expect(tokenKind_LParen, "("); err != nil {
+			return nil, err
+		}
+		candidates := make([]operand, 0)
+		for {
+			candidate, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, candidate)
+			if p.peek().kind != tokenKind_Comma {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect(tokenKind_RParen, ")"); err != nil {
+			return nil, err
+		}
+		return func(item map[string]types.AttributeValue) bool {
+			value := left(item)
+			for _, candidate := range candidates {
+				if equalValues(value, candidate(item)) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	operator := p.next()
+	if operator.kind != tokenKind_Operator {
+		return nil, errors.Errorf("expected comparator, found `%s`", operator.text)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(item map[string]types.AttributeValue) bool {
+		l, r := left(item), right(item)
+		if operator.text == "=" {
+			return equalValues(l, r)
+		}
+		if operator.text == "<>" {
+			return !equalValues(l, r)
+		}
+		cmp, ok := compareValues(l, r)
+		if !ok {
+			return false
+		}
+		switch operator.text {
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		case ">=":
+			return cmp >= 0
+		default:
+			return false
+		}
+	}, nil
+}
+
+func (p *parser) parseFunction() (condition, error) {
+	name := strings.ToLower(p.next().text)
+	if err := p.expect(tokenKind_LParen, "("); err != nil {
+		return nil, err
+	}
+	args := make([]token, 0)
+	for p.peek().kind != tokenKind_RParen {
+		tok := p.next()
+		if tok.kind == tokenKind_EOF {
+			return nil, errors.New("unterminated function call")
+		}
+		if tok.kind != tokenKind_Comma {
+			args = append(args, tok)
+		}
+	}
+	p.next()
+
+	switch name {
+	case "attribute_exists", "attribute_not_exists":
+		if len(args) != 1 {
+			return nil, errors.Errorf("%s expects one argument", name)
+		}
+		attribute, err := p.context.attributeName(args[0])
+		if err != nil {
+			return nil, err
+		}
+		exists := name == "attribute_exists"
+		return func(item map[string]types.AttributeValue) bool {
+			_, ok := item[attribute]
+			return ok == exists
+		}, nil
+	case "begins_with", "contains":
+		if len(args) != 2 {
+			return nil, errors.Errorf("%s expects two arguments", name)
+		}
+		attribute, err := p.context.attributeName(args[0])
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.valueOperand(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return func(item map[string]types.AttributeValue) bool {
+			target, ok := item[attribute].(*types.AttributeValueMemberS)
+			prefix, okPrefix := value(item).(*types.AttributeValueMemberS)
+			if !ok || !okPrefix {
+				return false
+			}
+			if name == "begins_with" {
+				return strings.HasPrefix(target.Value, prefix.Value)
+			}
+			return strings.Contains(target.Value, prefix.Value)
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported function `%s`", name)
+	}
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	return p.valueOperand(p.next())
+}
+
+func (p *parser) valueOperand(tok token) (operand, error) {
+	if tok.kind == tokenKind_Value {
+		value, ok := p.context.values[tok.text]
+		if !ok {
+			return nil, errors.Errorf("expression attribute value `%s` not defined", tok.text)
+		}
+		return func(map[string]types.AttributeValue) types.AttributeValue { return value }, nil
+	}
+	attribute, err := p.context.attributeName(tok)
+	if err != nil {
+		return nil, err
+	}
+	return func(item map[string]types.AttributeValue) types.AttributeValue { return item[attribute] }, nil
+}
+
+type updateAction struct {
+	set    map[string]operand
+	remove []string
+}
+
+func compileUpdate(expression *string, ctx *expressionContext) (*updateAction, error) {
+	action := &updateAction{
+		set:    make(map[string]operand),
+		remove: make([]string, 0),
+	}
+	if expression == nil {
+		return action, nil
+	}
+	tokens, err := tokenize(*expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, context: ctx}
+	for p.peek().kind != tokenKind_EOF {
+		clause := p.next()
+		switch {
+		case clause.kind == tokenKind_Ident && strings.EqualFold(clause.text, "SET"):
+			for {
+				attribute, err := ctx.attributeName(p.next())
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(tokenKind_Operator, "="); err != nil {
+					return nil, err
+				}
+				value, err := p.parseArithmetic()
+				if err != nil {
+					return nil, err
+				}
+				action.set[attribute] = value
+				if p.peek().kind != tokenKind_Comma {
+					break
+				}
+				p.next()
+			}
+		case clause.kind == tokenKind_Ident && strings.EqualFold(clause.text, "REMOVE"):
+			for {
+				attribute, err := ctx.attributeName(p.next())
+				if err != nil {
+					return nil, err
+				}
+				action.remove = append(action.remove, attribute)
+				if p.peek().kind != tokenKind_Comma {
+					break
+				}
+				p.next()
+			}
+		default:
+			return nil, errors.Errorf("unsupported update clause `%s`", clause.text)
+		}
+	}
+	return action, nil
+}
+
+func (p *parser) parseArithmetic() (operand, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if tok.kind != tokenKind_Operator || (tok.text != "+" && tok.text != "-") {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return func(item map[string]types.AttributeValue) types.AttributeValue {
+		l, okLeft := toNumber(left(item))
+		r, okRight := toNumber(right(item))
+		if !okLeft || !okRight {
+			return nil
+		}
+		if tok.text == "+" {
+			return &types.AttributeValueMemberN{Value: l.Add(l, r).Text('f', -1)}
+		}
+		return &types.AttributeValueMemberN{Value: l.Sub(l, r).Text('f', -1)}
+	}, nil
+}
+
+func (a *updateAction) apply(item map[string]types.AttributeValue) {
+	values := make(map[string]types.AttributeValue)
+	for attribute, value := range a.set {
+		values[attribute] = value(item)
+	}
+	for attribute, value := range values {
+		if value != nil {
+			item[attribute] = value
+		}
+	}
+	for _, attribute := range a.remove {
+		delete(item, attribute)
+	}
+}
+
+func toNumber(value types.AttributeValue) (*big.Float, bool) {
+	number, ok := value.(*types.AttributeValueMemberN)
+	if !ok {
+		return nil, false
+	}
+	parsed, _, err := big.ParseFloat(number.Value, 10, 128, big.ToNearestEven)
+	if err != nil {
+		return nil, false
+	}
+	return parsed, true
+}
+
+func compareValues(left types.AttributeValue, right types.AttributeValue) (int, bool) {
+	switch l := left.(type) {
+	case *types.AttributeValueMemberS:
+		r, ok := right.(*types.AttributeValueMemberS)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(l.Value, r.Value), true
+	case *types.AttributeValueMemberN:
+		ln, okLeft := toNumber(l)
+		rn, okRight := toNumber(right)
+		if !okLeft || !okRight {
+			return 0, false
+		}
+		return ln.Cmp(rn), true
+	case *types.AttributeValueMemberB:
+		r, ok := right.(*types.AttributeValueMemberB)
+		if !ok {
+			return 0, false
+		}
+		return bytes.Compare(l.Value, r.Value), true
+	default:
+		return 0, false
+	}
+}
+
+func equalValues(left types.AttributeValue, right types.AttributeValue) bool {
+	if left == nil || right == nil {
+		return false
+	}
+	if cmp, ok := compareValues(left, right); ok {
+		return cmp == 0
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func keyString(value types.AttributeValue) string {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return "S:" + v.Value
+	case *types.AttributeValueMemberN:
+		if number, ok := toNumber(v); ok {
+			return "N:" + number.Text('g', -1)
+		}
+		return "N:" + v.Value
+	case *types.AttributeValueMemberB:
+		return fmt.Sprintf("B:%x", v.Value)
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
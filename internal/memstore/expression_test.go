@@ -0,0 +1,159 @@
+package memstore
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestCompileCondition(t *testing.T) {
+
+	item := map[string]types.AttributeValue{
+		"id":       &types.AttributeValueMemberS{Value: "01HK0000000000000000000002"},
+		"version":  &types.AttributeValueMemberN{Value: "7"},
+		"__status": &types.AttributeValueMemberS{Value: "alive"},
+		"__space":  &types.AttributeValueMemberS{Value: "alive#Order"},
+		"flag":     &types.AttributeValueMemberBOOL{Value: true},
+		"name":     &types.AttributeValueMemberS{Value: "blue book"},
+	}
+	names := map[string]string{
+		"#id":      "id",
+		"#version": "version",
+		"#status":  "__status",
+		"#space":   "__space",
+		"#flag":    "flag",
+		"#name":    "name",
+		"#missing": "missing",
+	}
+	values := map[string]types.AttributeValue{
+		":id":      &types.AttributeValueMemberS{Value: "01HK0000000000000000000002"},
+		":low":     &types.AttributeValueMemberS{Value: "01HK0000000000000000000001"},
+		":high":    &types.AttributeValueMemberS{Value: "01HK0000000000000000000003"},
+		":prefix":  &types.AttributeValueMemberS{Value: "01HK"},
+		":other":   &types.AttributeValueMemberS{Value: "01HZ"},
+		":six":     &types.AttributeValueMemberN{Value: "6"},
+		":seven":   &types.AttributeValueMemberN{Value: "7.0"},
+		":eight":   &types.AttributeValueMemberN{Value: "8"},
+		":alive":   &types.AttributeValueMemberS{Value: "alive"},
+		":space":   &types.AttributeValueMemberS{Value: "alive#Order"},
+		":dead":    &types.AttributeValueMemberS{Value: "dead"},
+		":true":    &types.AttributeValueMemberBOOL{Value: true},
+		":book":    &types.AttributeValueMemberS{Value: "book"},
+		":numeric": &types.AttributeValueMemberN{Value: "1"},
+	}
+
+	tests := []struct {
+		name       string
+		expression *string
+		expected   bool
+	}{
+		{"empty expression", nil, true},
+		{"equal string", jsii.String("#id = :id"), true},
+		{"equal number with different text", jsii.String("#version = :seven"), true},
+		{"not equal", jsii.String("#status <> :dead"), true},
+		{"less than", jsii.String("#version < :eight"), true},
+		{"less than or equal", jsii.String("#version <= :six"), false},
+		{"greater than", jsii.String("#version > :six"), true},
+		{"greater than or equal", jsii.String("#version >= :eight"), false},
+		{"string compared with number", jsii.String("#id > :numeric"), false},
+		{"between inclusive bounds", jsii.String("#id BETWEEN :id AND :high"), true},
+		{"between outside bounds", jsii.String("#id between :high AND :high"), false},
+		{"begins_with", jsii.String("begins_with(#id, :prefix)"), true},
+		{"begins_with mismatch", jsii.String("begins_with(#id, :other)"), false},
+		{"contains", jsii.String("contains(#name, :book)"), true},
+		{"attribute_exists", jsii.String("attribute_exists(#flag)"), true},
+		{"attribute_not_exists", jsii.String("attribute_not_exists(#missing)"), true},
+		{"bool equality", jsii.String("#flag = :true"), true},
+		{"in list", jsii.String("#status IN (:dead, :alive)"), true},
+		{"and", jsii.String("#status = :alive AND #version = :seven"), true},
+		{"or", jsii.String("#status = :dead OR #version = :seven"), true},
+		{"not", jsii.String("NOT #status = :dead"), true},
+		{"parenthesis precedence", jsii.String("#status = :dead AND (#version = :seven OR #flag = :true)"), false},
+		{"and binds tighter than or", jsii.String("#status = :dead AND #version = :seven OR #flag = :true"), true},
+		{"missing attribute comparison", jsii.String("#missing = :alive"), false},
+		{"key condition with range", jsii.String("#space = :space AND #id BETWEEN :low AND :high"), true},
+		{"key condition with other partition", jsii.String("#space = :alive AND #id BETWEEN :low AND :high"), false},
+		{"snapshot condition", jsii.String("attribute_not_exists(#id) OR (#flag = :true AND #version < :eight)"), true},
+	}
+
+	ctx := &expressionContext{names: names, values: values}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cond, err := compileCondition(test.expression, ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := cond(item); actual != test.expected {
+				t.Fatalf("expected %v, found %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCompileCondition_Errors(t *testing.T) {
+
+	ctx := &expressionContext{
+		names:  map[string]string{"#id": "id"},
+		values: map[string]types.AttributeValue{":id": &types.AttributeValueMemberS{Value: "x"}},
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"undefined name", "#missing = :id"},
+		{"undefined value", "#id = :missing"},
+		{"unexpected character", "#id ! :id"},
+		{"between without and", "#id BETWEEN :id :id"},
+		{"missing comparator", "#id :id"},
+		{"unbalanced parenthesis", "(#id = :id"},
+		{"trailing tokens", "#id = :id :id"},
+		{"unknown function", "size(#id) = :id"},
+		{"begins_with arity", "begins_with(#id)"},
+		{"unterminated function", "attribute_exists(#id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := compileCondition(jsii.String(test.expression), ctx); err == nil {
+				t.Fatalf("expected error compiling `%s`", test.expression)
+			}
+		})
+	}
+}
+
+func TestCompileUpdate(t *testing.T) {
+
+	ctx := &expressionContext{
+		names: map[string]string{"#version": "version", "#name": "name", "#old": "old"},
+		values: map[string]types.AttributeValue{
+			":one":  &types.AttributeValueMemberN{Value: "1"},
+			":name": &types.AttributeValueMemberS{Value: "new"},
+		},
+	}
+	item := map[string]types.AttributeValue{
+		"version": &types.AttributeValueMemberN{Value: "2"},
+		"old":     &types.AttributeValueMemberS{Value: "gone"},
+	}
+
+	action, err := compileUpdate(jsii.String("SET #version = #version + :one, #name = :name REMOVE #old"), ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	action.apply(item)
+
+	if version := item["version"].(*types.AttributeValueMemberN).Value; version != "3" {
+		t.Fatalf("expected version 3, found %s", version)
+	}
+	if name := item["name"].(*types.AttributeValueMemberS).Value; name != "new" {
+		t.Fatalf("expected name `new`, found `%s`", name)
+	}
+	if _, ok := item["old"]; ok {
+		t.Fatalf("expected `old` to be removed")
+	}
+
+	if _, err = compileUpdate(jsii.String("ADD #version :one"), ctx); err == nil {
+		t.Fatalf("expected error for unsupported update clause")
+	}
+}
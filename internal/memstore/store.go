@@ -0,0 +1,444 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/pkg/errors"
+)
+
+type TableSchema struct {
+	PartitionKey string
+	SortKey      string
+}
+
+type IndexSchema struct {
+	PartitionKey string
+	SortKey      string
+}
+
+func GetTableSchema(table string) TableSchema {
+	switch {
+	case strings.HasSuffix(table, "-commandstore"),
+		strings.HasSuffix(table, "-eventstore"):
+		return TableSchema{PartitionKey: "source", SortKey: "id"}
+	default:
+		return TableSchema{PartitionKey: "id"}
+	}
+}
+
+func GetIndexSchema(index string) IndexSchema {
+	if index == "by-space" {
+		return IndexSchema{PartitionKey: "__space", SortKey: "id"}
+	}
+	return IndexSchema{PartitionKey: fmt.Sprintf("__%s-pk", index), SortKey: "id"}
+}
+
+type Store struct {
+	mutex  sync.RWMutex
+	tables map[string]map[string]map[string]types.AttributeValue
+}
+
+func NewStore() *Store {
+	return &Store{
+		tables: make(map[string]map[string]map[string]types.AttributeValue),
+	}
+}
+
+func (s *Store) Items(table string) []map[string]types.AttributeValue {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	schema := GetTableSchema(table)
+	items := make([]map[string]types.AttributeValue, 0, len(s.tables[table]))
+	for _, item := range s.tables[table] {
+		items = append(items, copyItem(item))
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return compareKeys(items[i], items[j], schema.PartitionKey, schema.SortKey) < 0
+	})
+	return items
+}
+
+func (s *Store) Put(table string, item map[string]types.AttributeValue) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	key, err := itemKey(table, item)
+	if err != nil {
+		return err
+	}
+	s.table(table)[key] = copyItem(item)
+	return nil
+}
+
+func (s *Store) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tables = make(map[string]map[string]map[string]types.AttributeValue)
+}
+
+func (s *Store) GetItem(
+	ctx context.Context,
+	params *dynamodb.GetItemInput,
+	optFns ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	table := aws.ToString(params.TableName)
+	key, err := itemKey(table, params.Key)
+	if err != nil {
+		return nil, err
+	}
+	item, ok := s.tables[table][key]
+	if !ok {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	return &dynamodb.GetItemOutput{Item: copyItem(item)}, nil
+}
+
+func (s *Store) BatchGetItem(
+	ctx context.Context,
+	params *dynamodb.BatchGetItemInput,
+	optFns ...func(*dynamodb.Options),
+) (*dynamodb.BatchGetItemOutput, error) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	responses := make(map[string][]map[string]types.AttributeValue)
+	for table, request := range params.RequestItems {
+		responses[table] = make([]map[string]types.AttributeValue, 0)
+		seen := make(map[string]bool)
+		for _, requestKey := range request.Keys {
+			key, err := itemKey(table, requestKey)
+			if err != nil {
+				return nil, err
+			}
+			if seen[key] {
+				return nil, errors.New("provided list of item keys contains duplicates")
+			}
+			seen[key] = true
+			if item, ok := s.tables[table][key]; ok {
+				responses[table] = append(responses[table], copyItem(item))
+			}
+		}
+	}
+	return &dynamodb.BatchGetItemOutput{
+		Responses:       responses,
+		UnprocessedKeys: map[string]types.KeysAndAttributes{},
+	}, nil
+}
+
+func (s *Store) Query(
+	ctx context.Context,
+	params *dynamodb.QueryInput,
+	optFns ...func(*dynamodb.Options),
+) (*dynamodb.QueryOutput, error) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	table := aws.ToString(params.TableName)
+	tableSchema := GetTableSchema(table)
+	partitionKey, sortKey := tableSchema.PartitionKey, tableSchema.SortKey
+	if params.IndexName != nil {
+		indexSchema := GetIndexSchema(*params.IndexName)
+		partitionKey, sortKey = indexSchema.PartitionKey, indexSchema.SortKey
+	}
+
+	exprContext := &expressionContext{
+		names:  params.ExpressionAttributeNames,
+		values: params.ExpressionAttributeValues,
+	}
+	keyCondition, err := compileCondition(params.KeyConditionExpression, exprContext)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key condition expression")
+	}
+	filter, err := compileCondition(params.FilterExpression, exprContext)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid filter expression")
+	}
+
+	candidates := make([]map[string]types.AttributeValue, 0)
+	for _, item := range s.tables[table] {
+		if _, ok := item[partitionKey]; !ok {
+			continue
+		}
+		if sortKey != "" {
+			if _, ok := item[sortKey]; !ok {
+				continue
+			}
+		}
+		if keyCondition(item) {
+			candidates = append(candidates, item)
+		}
+	}
+
+	forward := params.ScanIndexForward == nil || *params.ScanIndexForward
+	sort.SliceStable(candidates, func(i, j int) bool {
+		cmp := compareKeys(candidates[i], candidates[j], sortKey, tableSchema.PartitionKey)
+		if cmp == 0 {
+			cmp = compareKeys(candidates[i], candidates[j], tableSchema.SortKey, "")
+		}
+		if forward {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+
+	if len(params.ExclusiveStartKey) > 0 {
+		position := len(candidates)
+		for idx, item := range candidates {
+			cmp := compareKeys(item, params.ExclusiveStartKey, sortKey, tableSchema.PartitionKey)
+			if cmp == 0 {
+				cmp = compareKeys(item, params.ExclusiveStartKey, tableSchema.SortKey, "")
+			}
+			if (forward && cmp > 0) || (!forward && cmp < 0) {
+				position = idx
+				break
+			}
+		}
+		candidates = candidates[position:]
+	}
+
+	limit := len(candidates)
+	if params.Limit != nil && int(*params.Limit) < limit {
+		limit = int(*params.Limit)
+	}
+
+	items := make([]map[string]types.AttributeValue, 0)
+	for _, item := range candidates[:limit] {
+		if filter(item) {
+			items = append(items, copyItem(item))
+		}
+	}
+
+	output := &dynamodb.QueryOutput{
+		Items:        items,
+		Count:        int32(len(items)),
+		ScannedCount: int32(limit),
+	}
+	if limit < len(candidates) && limit > 0 {
+		last := candidates[limit-1]
+		lastKey := map[string]types.AttributeValue{}
+		for _, attribute := range []string{partitionKey, sortKey, tableSchema.PartitionKey, tableSchema.SortKey} {
+			if attribute != "" {
+				lastKey[attribute] = last[attribute]
+			}
+		}
+		output.LastEvaluatedKey = lastKey
+	}
+	return output, nil
+}
+
+func (s *Store) TransactWriteItems(
+	ctx context.Context,
+	params *dynamodb.TransactWriteItemsInput,
+	optFns ...func(*dynamodb.Options),
+) (*dynamodb.TransactWriteItemsOutput, error) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(params.TransactItems) == 0 || len(params.TransactItems) > 100 {
+		return nil, errors.New("transaction must contain between 1 and 100 items")
+	}
+
+	type operation struct {
+		table string
+		key   string
+		apply func(current map[string]types.AttributeValue) map[string]types.AttributeValue
+	}
+
+	operations := make([]operation, 0, len(params.TransactItems))
+	reasons := make([]types.CancellationReason, len(params.TransactItems))
+	canceled := false
+	touched := make(map[string]bool)
+
+	for idx, transactItem := range params.TransactItems {
+
+		var (
+			table      string
+			rawKey     map[string]types.AttributeValue
+			expression *string
+			names      map[string]string
+			values     map[string]types.AttributeValue
+			apply      func(current map[string]types.AttributeValue) map[string]types.AttributeValue
+		)
+
+		switch {
+		case transactItem.Put != nil:
+			put := transactItem.Put
+			table, rawKey = aws.ToString(put.TableName), put.Item
+			expression, names, values = put.ConditionExpression, put.ExpressionAttributeNames, put.ExpressionAttributeValues
+			item := copyItem(put.Item)
+			apply = func(map[string]types.AttributeValue) map[string]types.AttributeValue { return item }
+		case transactItem.Update != nil:
+			update := transactItem.Update
+			table, rawKey = aws.ToString(update.TableName), update.Key
+			expression, names, values = update.ConditionExpression, update.ExpressionAttributeNames, update.ExpressionAttributeValues
+			action, err := compileUpdate(update.UpdateExpression, &expressionContext{names: names, values: values})
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid update expression")
+			}
+			key := copyItem(update.Key)
+			apply = func(current map[string]types.AttributeValue) map[string]types.AttributeValue {
+				item := copyItem(current)
+				if item == nil {
+					item = key
+				}
+				action.apply(item)
+				return item
+			}
+		case transactItem.Delete != nil:
+			del := transactItem.Delete
+			table, rawKey = aws.ToString(del.TableName), del.Key
+			expression, names, values = del.ConditionExpression, del.ExpressionAttributeNames, del.ExpressionAttributeValues
+			apply = func(map[string]types.AttributeValue) map[string]types.AttributeValue { return nil }
+		case transactItem.ConditionCheck != nil:
+			check := transactItem.ConditionCheck
+			table, rawKey = aws.ToString(check.TableName), check.Key
+			expression, names, values = check.ConditionExpression, check.ExpressionAttributeNames, check.ExpressionAttributeValues
+		default:
+			return nil, errors.New("empty transaction item")
+		}
+
+		key, err := itemKey(table, rawKey)
+		if err != nil {
+			return nil, err
+		}
+		if touched[table+"|"+key] {
+			return nil, errors.New("transaction contains multiple operations on one item")
+		}
+		touched[table+"|"+key] = true
+
+		cond, err := compileCondition(expression, &expressionContext{names: names, values: values})
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid condition expression")
+		}
+
+		current := s.tables[table][key]
+		if current == nil {
+			current = map[string]types.AttributeValue{}
+		}
+		if cond(current) {
+			reasons[idx] = types.CancellationReason{Code: jsii.String("None")}
+		} else {
+			reasons[idx] = types.CancellationReason{
+				Code:    jsii.String("ConditionalCheckFailed"),
+				Message: jsii.String("The conditional request failed"),
+			}
+			canceled = true
+		}
+
+		if apply != nil {
+			operations = append(operations, operation{table: table, key: key, apply: apply})
+		}
+	}
+
+	if canceled {
+		return nil, &types.TransactionCanceledException{
+			Message:             jsii.String("Transaction cancelled, please refer cancellation reasons for specific reasons"),
+			CancellationReasons: reasons,
+		}
+	}
+
+	for _, op := range operations {
+		next := op.apply(s.tables[op.table][op.key])
+		if next == nil {
+			delete(s.table(op.table), op.key)
+		} else {
+			s.table(op.table)[op.key] = next
+		}
+	}
+
+	return &dynamodb.TransactWriteItemsOutput{
+		ConsumedCapacity: []types.ConsumedCapacity{},
+	}, nil
+}
+
+func (s *Store) table(name string) map[string]map[string]types.AttributeValue {
+	table, ok := s.tables[name]
+	if !ok {
+		table = make(map[string]map[string]types.AttributeValue)
+		s.tables[name] = table
+	}
+	return table
+}
+
+func itemKey(table string, item map[string]types.AttributeValue) (string, error) {
+	schema := GetTableSchema(table)
+	partition, ok := item[schema.PartitionKey]
+	if !ok {
+		return "", errors.Errorf("missing partition key `%s` for table `%s`", schema.PartitionKey, table)
+	}
+	if schema.SortKey == "" {
+		return keyString(partition), nil
+	}
+	sortValue, ok := item[schema.SortKey]
+	if !ok {
+		return "", errors.Errorf("missing sort key `%s` for table `%s`", schema.SortKey, table)
+	}
+	return keyString(partition) + "|" + keyString(sortValue), nil
+}
+
+func compareKeys(left map[string]types.AttributeValue, right map[string]types.AttributeValue, primary string, secondary string) int {
+	for _, attribute := range []string{primary, secondary} {
+		if attribute == "" {
+			continue
+		}
+		cmp, ok := compareValues(left[attribute], right[attribute])
+		if ok && cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+func copyItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	copied := make(map[string]types.AttributeValue, len(item))
+	for key, value := range item {
+		copied[key] = copyValue(value)
+	}
+	return copied
+}
+
+func copyValue(value types.AttributeValue) types.AttributeValue {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: copyItem(v.Value)}
+	case *types.AttributeValueMemberL:
+		list := make([]types.AttributeValue, len(v.Value))
+		for idx, item := range v.Value {
+			list[idx] = copyValue(item)
+		}
+		return &types.AttributeValueMemberL{Value: list}
+	case *types.AttributeValueMemberSS:
+		return &types.AttributeValueMemberSS{Value: append([]string{}, v.Value...)}
+	case *types.AttributeValueMemberNS:
+		return &types.AttributeValueMemberNS{Value: append([]string{}, v.Value...)}
+	case *types.AttributeValueMemberB:
+		return &types.AttributeValueMemberB{Value: append([]byte{}, v.Value...)}
+	case *types.AttributeValueMemberS:
+		return &types.AttributeValueMemberS{Value: v.Value}
+	case *types.AttributeValueMemberN:
+		return &types.AttributeValueMemberN{Value: v.Value}
+	case *types.AttributeValueMemberBOOL:
+		return &types.AttributeValueMemberBOOL{Value: v.Value}
+	case *types.AttributeValueMemberNULL:
+		return &types.AttributeValueMemberNULL{Value: v.Value}
+	default:
+		return value
+	}
+}
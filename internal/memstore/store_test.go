@@ -0,0 +1,407 @@
+package memstore_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/internal/memstore"
+)
+
+const testStateTable = "dyn-cvxtest-cvxtest-statestore"
+
+func seedStateItems(t *testing.T, store *memstore.Store) {
+	t.Helper()
+	for idx := 1; idx <= 6; idx++ {
+		typename := "Order"
+		if idx%2 == 0 {
+			typename = "Invoice"
+		}
+		item := map[string]types.AttributeValue{
+			"id":         &types.AttributeValueMemberS{Value: fmt.Sprintf("%02d", idx)},
+			"__typename": &types.AttributeValueMemberS{Value: typename},
+			"__space":    &types.AttributeValueMemberS{Value: "alive#Any"},
+			"version":    &types.AttributeValueMemberN{Value: "1"},
+		}
+		if idx <= 4 {
+			item["__by-owner-pk"] = &types.AttributeValueMemberS{Value: "alice"}
+		}
+		if err := store.Put(testStateTable, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func itemIDs(items []map[string]types.AttributeValue) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item["id"].(*types.AttributeValueMemberS).Value)
+	}
+	return ids
+}
+
+func TestStore_Query(t *testing.T) {
+
+	store := memstore.NewStore()
+	seedStateItems(t, store)
+
+	spaceQuery := func() *dynamodb.QueryInput {
+		return &dynamodb.QueryInput{
+			TableName:                jsii.String(testStateTable),
+			IndexName:                jsii.String("by-space"),
+			KeyConditionExpression:   jsii.String("#space = :space"),
+			ExpressionAttributeNames: map[string]string{"#space": "__space"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":space": &types.AttributeValueMemberS{Value: "alive#Any"},
+			},
+		}
+	}
+	withFilter := func(input *dynamodb.QueryInput) *dynamodb.QueryInput {
+		input.FilterExpression = jsii.String("#type = :type")
+		input.ExpressionAttributeNames["#type"] = "__typename"
+		input.ExpressionAttributeValues[":type"] = &types.AttributeValueMemberS{Value: "Order"}
+		return input
+	}
+
+	tests := []struct {
+		name     string
+		input    *dynamodb.QueryInput
+		ids      []string
+		scanned  int32
+		lastKey  string
+		modifier func(input *dynamodb.QueryInput)
+	}{
+		{
+			name:    "ascending by default",
+			input:   spaceQuery(),
+			ids:     []string{"01", "02", "03", "04", "05", "06"},
+			scanned: 6,
+		},
+		{
+			name:     "descending",
+			input:    spaceQuery(),
+			ids:      []string{"06", "05", "04", "03", "02", "01"},
+			scanned:  6,
+			modifier: func(input *dynamodb.QueryInput) { input.ScanIndexForward = jsii.Bool(false) },
+		},
+		{
+			name:     "limit returns last evaluated key",
+			input:    spaceQuery(),
+			ids:      []string{"01", "02"},
+			scanned:  2,
+			lastKey:  "02",
+			modifier: func(input *dynamodb.QueryInput) { input.Limit = aws.Int32(2) },
+		},
+		{
+			name:    "exclusive start key resumes after key",
+			input:   spaceQuery(),
+			ids:     []string{"03", "04"},
+			scanned: 2,
+			lastKey: "04",
+			modifier: func(input *dynamodb.QueryInput) {
+				input.Limit = aws.Int32(2)
+				input.ExclusiveStartKey = map[string]types.AttributeValue{
+					"__space": &types.AttributeValueMemberS{Value: "alive#Any"},
+					"id":      &types.AttributeValueMemberS{Value: "02"},
+				}
+			},
+		},
+		{
+			name:     "limit equal to remaining items has no last key",
+			input:    spaceQuery(),
+			ids:      []string{"01", "02", "03", "04", "05", "06"},
+			scanned:  6,
+			modifier: func(input *dynamodb.QueryInput) { input.Limit = aws.Int32(6) },
+		},
+		{
+			name:     "filter applies after limit",
+			input:    withFilter(spaceQuery()),
+			ids:      []string{"01", "03"},
+			scanned:  4,
+			lastKey:  "04",
+			modifier: func(input *dynamodb.QueryInput) { input.Limit = aws.Int32(4) },
+		},
+		{
+			name:    "sort key condition",
+			input:   spaceQuery(),
+			ids:     []string{"02", "03", "04"},
+			scanned: 3,
+			modifier: func(input *dynamodb.QueryInput) {
+				input.KeyConditionExpression = jsii.String("#space = :space AND #id BETWEEN :low AND :high")
+				input.ExpressionAttributeNames["#id"] = "id"
+				input.ExpressionAttributeValues[":low"] = &types.AttributeValueMemberS{Value: "02"}
+				input.ExpressionAttributeValues[":high"] = &types.AttributeValueMemberS{Value: "04"}
+			},
+		},
+		{
+			name: "sparse index only returns items with index key",
+			input: &dynamodb.QueryInput{
+				TableName:                jsii.String(testStateTable),
+				IndexName:                jsii.String("by-owner"),
+				KeyConditionExpression:   jsii.String("#owner = :owner"),
+				ExpressionAttributeNames: map[string]string{"#owner": "__by-owner-pk"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":owner": &types.AttributeValueMemberS{Value: "alice"},
+				},
+				ScanIndexForward: jsii.Bool(false),
+			},
+			ids:     []string{"04", "03", "02", "01"},
+			scanned: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.modifier != nil {
+				test.modifier(test.input)
+			}
+			output, err := store.Query(context.Background(), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cvxtest.DiffJSON(test.ids, itemIDs(output.Items)); diff != "" {
+				t.Fatalf("items mismatch (-expected +actual):\n%s", diff)
+			}
+			if output.Count != int32(len(test.ids)) || output.ScannedCount != test.scanned {
+				t.Fatalf("expected count %d and scanned %d, found %d and %d",
+					len(test.ids), test.scanned, output.Count, output.ScannedCount)
+			}
+			lastKey := ""
+			if output.LastEvaluatedKey != nil {
+				lastKey = output.LastEvaluatedKey["id"].(*types.AttributeValueMemberS).Value
+			}
+			if lastKey != test.lastKey {
+				t.Fatalf("expected last evaluated key `%s`, found `%s`", test.lastKey, lastKey)
+			}
+		})
+	}
+}
+
+func TestStore_Query_PagesThroughAllItems(t *testing.T) {
+
+	store := memstore.NewStore()
+	seedStateItems(t, store)
+
+	input := &dynamodb.QueryInput{
+		TableName:                jsii.String(testStateTable),
+		IndexName:                jsii.String("by-space"),
+		KeyConditionExpression:   jsii.String("#space = :space"),
+		ExpressionAttributeNames: map[string]string{"#space": "__space"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":space": &types.AttributeValueMemberS{Value: "alive#Any"},
+		},
+		ScanIndexForward: jsii.Bool(false),
+		Limit:            aws.Int32(4),
+	}
+
+	ids := make([]string, 0)
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("query did not finish paging")
+		}
+		output, err := store.Query(context.Background(), input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, itemIDs(output.Items)...)
+		if output.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+	if diff := cvxtest.DiffJSON([]string{"06", "05", "04", "03", "02", "01"}, ids); diff != "" {
+		t.Fatalf("items mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestStore_TransactWriteItems(t *testing.T) {
+
+	key := func(id string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}}
+	}
+	put := func(id string, version string, condition string) types.TransactWriteItem {
+		item := key(id)
+		item["version"] = &types.AttributeValueMemberN{Value: version}
+		write := &types.Put{TableName: jsii.String(testStateTable), Item: item}
+		if condition != "" {
+			write.ConditionExpression = jsii.String(condition)
+			write.ExpressionAttributeNames = map[string]string{"#id": "id"}
+		}
+		return types.TransactWriteItem{Put: write}
+	}
+	update := func(id string, previous string, next string) types.TransactWriteItem {
+		return types.TransactWriteItem{Update: &types.Update{
+			TableName:                jsii.String(testStateTable),
+			Key:                      key(id),
+			UpdateExpression:         jsii.String("SET #version = :next"),
+			ConditionExpression:      jsii.String("#version = :previous"),
+			ExpressionAttributeNames: map[string]string{"#version": "version"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":previous": &types.AttributeValueMemberN{Value: previous},
+				":next":     &types.AttributeValueMemberN{Value: next},
+			},
+		}}
+	}
+	remove := func(id string) types.TransactWriteItem {
+		return types.TransactWriteItem{Delete: &types.Delete{
+			TableName:                jsii.String(testStateTable),
+			Key:                      key(id),
+			ConditionExpression:      jsii.String("attribute_exists(#id)"),
+			ExpressionAttributeNames: map[string]string{"#id": "id"},
+		}}
+	}
+	check := func(id string) types.TransactWriteItem {
+		return types.TransactWriteItem{ConditionCheck: &types.ConditionCheck{
+			TableName:                jsii.String(testStateTable),
+			Key:                      key(id),
+			ConditionExpression:      jsii.String("attribute_exists(#id)"),
+			ExpressionAttributeNames: map[string]string{"#id": "id"},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		items    []types.TransactWriteItem
+		reasons  []string
+		err      bool
+		versions map[string]string
+	}{
+		{
+			name:     "put update and delete commit together",
+			items:    []types.TransactWriteItem{put("c", "1", "attribute_not_exists(#id)"), update("a", "1", "2"), remove("b")},
+			versions: map[string]string{"a": "2", "c": "1"},
+		},
+		{
+			name:     "failed condition cancels every item",
+			items:    []types.TransactWriteItem{put("c", "1", ""), update("a", "5", "6")},
+			reasons:  []string{"None", "ConditionalCheckFailed"},
+			versions: map[string]string{"a": "1", "b": "1"},
+		},
+		{
+			name:     "insert over existing item fails",
+			items:    []types.TransactWriteItem{put("a", "9", "attribute_not_exists(#id)")},
+			reasons:  []string{"ConditionalCheckFailed"},
+			versions: map[string]string{"a": "1", "b": "1"},
+		},
+		{
+			name:     "condition check on missing item fails",
+			items:    []types.TransactWriteItem{update("a", "1", "2"), check("missing")},
+			reasons:  []string{"None", "ConditionalCheckFailed"},
+			versions: map[string]string{"a": "1", "b": "1"},
+		},
+		{
+			name:     "multiple operations on one item are rejected",
+			items:    []types.TransactWriteItem{update("a", "1", "2"), remove("a")},
+			err:      true,
+			versions: map[string]string{"a": "1", "b": "1"},
+		},
+		{
+			name:     "empty transaction is rejected",
+			items:    []types.TransactWriteItem{},
+			err:      true,
+			versions: map[string]string{"a": "1", "b": "1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			store := memstore.NewStore()
+			for _, id := range []string{"a", "b"} {
+				item := key(id)
+				item["version"] = &types.AttributeValueMemberN{Value: "1"}
+				if err := store.Put(testStateTable, item); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := store.TransactWriteItems(context.Background(),
+				&dynamodb.TransactWriteItemsInput{TransactItems: test.items})
+
+			var canceled *types.TransactionCanceledException
+			switch {
+			case test.reasons != nil:
+				if !errors.As(err, &canceled) {
+					t.Fatalf("expected transaction canceled, found %v", err)
+				}
+				codes := make([]string, 0)
+				for _, reason := range canceled.CancellationReasons {
+					codes = append(codes, aws.ToString(reason.Code))
+				}
+				if diff := cvxtest.DiffJSON(test.reasons, codes); diff != "" {
+					t.Fatalf("cancellation reasons mismatch (-expected +actual):\n%s", diff)
+				}
+			case test.err:
+				if err == nil || errors.As(err, &canceled) {
+					t.Fatalf("expected validation error, found %v", err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			versions := make(map[string]string)
+			for _, item := range store.Items(testStateTable) {
+				id := item["id"].(*types.AttributeValueMemberS).Value
+				versions[id] = item["version"].(*types.AttributeValueMemberN).Value
+			}
+			if diff := cvxtest.DiffJSON(test.versions, versions); diff != "" {
+				t.Fatalf("stored versions mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStore_BatchGetItem(t *testing.T) {
+
+	store := memstore.NewStore()
+	seedStateItems(t, store)
+
+	keys := func(ids ...string) []map[string]types.AttributeValue {
+		result := make([]map[string]types.AttributeValue, 0, len(ids))
+		for _, id := range ids {
+			result = append(result, map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name string
+		keys []map[string]types.AttributeValue
+		ids  []string
+		err  bool
+	}{
+		{name: "existing items", keys: keys("01", "04"), ids: []string{"01", "04"}},
+		{name: "missing items are omitted", keys: keys("02", "99"), ids: []string{"02"}},
+		{name: "duplicate keys are rejected", keys: keys("01", "01"), err: true},
+		{name: "keys without partition key are rejected", keys: []map[string]types.AttributeValue{{}}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := store.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+				RequestItems: map[string]types.KeysAndAttributes{testStateTable: {Keys: test.keys}},
+			})
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(output.UnprocessedKeys) != 0 {
+				t.Fatalf("expected no unprocessed keys, found %d", len(output.UnprocessedKeys))
+			}
+			if diff := cvxtest.DiffJSON(test.ids, itemIDs(output.Responses[testStateTable])); diff != "" {
+				t.Fatalf("items mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
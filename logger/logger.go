@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	Level_Debug Level = iota - 1
+	Level_Info
+	Level_Warn
+	Level_Error
+)
+
+func (l Level) String() string {
+	switch l {
+	case Level_Debug:
+		return "DEBUG"
+	case Level_Info:
+		return "INFO"
+	case Level_Warn:
+		return "WARN"
+	case Level_Error:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+}
+
+func ParseLevel(value string) Level {
+	switch strings.ToUpper(value) {
+	case "DEBUG":
+		return Level_Debug
+	case "WARN":
+		return Level_Warn
+	case "ERROR":
+		return Level_Error
+	default:
+		return Level_Info
+	}
+}
+
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+	With(args ...interface{}) Logger
+}
+
+var defaultLogger Logger = NewJSONLogger(os.Stdout, ParseLevel(os.Getenv("CVX_LOG_LEVEL")))
+
+func Default() Logger {
+	return defaultLogger
+}
+
+func SetDefault(logger Logger) {
+	defaultLogger = logger
+}
+
+func NewJSONLogger(writer io.Writer, level Level) Logger {
+	return &jsonLogger{
+		output: &syncWriter{writer: writer},
+		level:  level,
+		fields: make([]interface{}, 0),
+	}
+}
+
+type syncWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func (w *syncWriter) Write(buffer []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.writer.Write(buffer)
+}
+
+type jsonLogger struct {
+	output *syncWriter
+	level  Level
+	fields []interface{}
+}
+
+func (l *jsonLogger) Debug(msg string, args ...interface{}) {
+	l.log(Level_Debug, msg, args...)
+}
+
+func (l *jsonLogger) Info(msg string, args ...interface{}) {
+	l.log(Level_Info, msg, args...)
+}
+
+func (l *jsonLogger) Warn(msg string, args ...interface{}) {
+	l.log(Level_Warn, msg, args...)
+}
+
+func (l *jsonLogger) Error(msg string, args ...interface{}) {
+	l.log(Level_Error, msg, args...)
+}
+
+func (l *jsonLogger) With(args ...interface{}) Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(args))
+	fields = append(fields, l.fields...)
+	fields = append(fields, args...)
+	return &jsonLogger{
+		output: l.output,
+		level:  l.level,
+		fields: fields,
+	}
+}
+
+func (l *jsonLogger) log(level Level, msg string, args ...interface{}) {
+
+	if level < l.level {
+		return
+	}
+
+	entry := map[string]interface{}{
+		"time":  time.Now().UTC().Format(time.RFC3339Nano),
+		"level": level.String(),
+		"msg":   msg,
+	}
+	appendFields(entry, l.fields)
+	appendFields(entry, args)
+
+	buffer, err := json.Marshal(entry)
+	if err != nil {
+		buffer, _ = json.Marshal(map[string]interface{}{
+			"time":  entry["time"],
+			"level": entry["level"],
+			"msg":   msg,
+			"error": fmt.Sprintf("cannot marshal log entry: %v", err),
+		})
+	}
+	_, _ = l.output.Write(append(buffer, '\n'))
+}
+
+func appendFields(entry map[string]interface{}, args []interface{}) {
+	for idx := 0; idx < len(args); idx += 2 {
+		key, ok := args[idx].(string)
+		if !ok {
+			key = fmt.Sprint(args[idx])
+		}
+		if idx+1 >= len(args) {
+			entry["!BADKEY"] = key
+			break
+		}
+		switch value := args[idx+1].(type) {
+		case error:
+			entry[key] = value.Error()
+		case time.Duration:
+			entry[key] = value.Milliseconds()
+		case time.Time:
+			entry[key] = value.UTC().Format(time.RFC3339Nano)
+		case fmt.Stringer:
+			entry[key] = value.String()
+		default:
+			entry[key] = value
+		}
+	}
+}
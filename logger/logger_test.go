@@ -0,0 +1,80 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cevixe/sdk/logger"
+	"github.com/pkg/errors"
+)
+
+func readEntries(t *testing.T, output *bytes.Buffer) []map[string]interface{} {
+	entries := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log entry `%s`: %v", line, err)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, entry["time"].(string)); err != nil {
+			t.Fatalf("invalid log time: %v", err)
+		}
+		delete(entry, "time")
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestJSONLogger(t *testing.T) {
+
+	output := &bytes.Buffer{}
+	log := logger.NewJSONLogger(output, logger.Level_Info)
+	log.Debug("skipped")
+
+	child := log.With("app", "shop", "domain", "sales")
+	child.Info("written", "entities", 2, "duration", 1500*time.Millisecond)
+	child.With("handler", "orders").Warn("retrying", "error", errors.New("conflict"))
+	log.Error("failed", "threshold", logger.Level_Warn, "dangling")
+
+	expected := []map[string]interface{}{
+		{"level": "INFO", "msg": "written", "app": "shop", "domain": "sales", "entities": float64(2), "duration": float64(1500)},
+		{"level": "WARN", "msg": "retrying", "app": "shop", "domain": "sales", "handler": "orders", "error": "conflict"},
+		{"level": "ERROR", "msg": "failed", "threshold": "WARN", "!BADKEY": "dangling"},
+	}
+	entries := readEntries(t, output)
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d log entries, found %d: %v", len(expected), len(entries), entries)
+	}
+	for idx := range expected {
+		for key, value := range expected[idx] {
+			if entries[idx][key] != value {
+				t.Fatalf("entry %d: expected %s=%v, found %v", idx, key, value, entries[idx][key])
+			}
+		}
+		if len(entries[idx]) != len(expected[idx]) {
+			t.Fatalf("entry %d: unexpected fields %v", idx, entries[idx])
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+
+	tests := map[string]logger.Level{
+		"debug": logger.Level_Debug,
+		"WARN":  logger.Level_Warn,
+		"Error": logger.Level_Error,
+		"info":  logger.Level_Info,
+		"":      logger.Level_Info,
+		"trace": logger.Level_Info,
+	}
+	for value, expected := range tests {
+		if level := logger.ParseLevel(value); level != expected {
+			t.Fatalf("expected level %s for `%s`, found %s", expected, value, level)
+		}
+	}
+}
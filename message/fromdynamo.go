@@ -0,0 +1,28 @@
+package message
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+)
+
+func FromDynamodb_TableMap(input map[string]types.AttributeValue) (Message, error) {
+
+	messageMap := make(map[string]interface{})
+	if err := attributevalue.UnmarshalMap(input, &messageMap); err != nil {
+		return nil, errors.Wrap(err, "invalid dynamodb record")
+	}
+
+	if err := validateMessageMapRequiredFields(messageMap); err != nil {
+		return nil, errors.Wrap(err, "invalid message map")
+	}
+
+	messageJson, err := json.Marshal(messageMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal dynamodb message map")
+	}
+
+	return FromJson(messageJson)
+}
@@ -68,7 +68,9 @@ func FromSNS(input *events.SNSEntity) (Message, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "message transaction not found")
 	}
-	messageTrigger, _ := getSNSEntityStringAttribute(input, "transaction")
+	messageTrigger, _ := getSNSEntityStringAttribute(input, "trigger")
+	messageTraceParent, _ := getSNSEntityStringAttribute(input, "traceparent")
+	messageTraceState, _ := getSNSEntityStringAttribute(input, "tracestate")
 
 	msg.MessageSource = messageSource
 	msg.MessageID = messageID
@@ -81,6 +83,8 @@ func FromSNS(input *events.SNSEntity) (Message, error) {
 	msg.MessageAuthor = messageAuthor
 	msg.MessageTrigger = messageTrigger
 	msg.MessageTransaction = messageTransaction
+	msg.MessageTraceParent = messageTraceParent
+	msg.MessageTraceState = messageTraceState
 
 	return msg, nil
 }
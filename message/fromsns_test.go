@@ -0,0 +1,53 @@
+package message_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/message"
+)
+
+func TestFromSNS_ReadsTrigger(t *testing.T) {
+
+	sent := cvxtest.NewEvent(&cvxtest.MessageProps{
+		Source:      "/order/01H0000000000000000000000",
+		ID:          "00000000000000000001",
+		Type:        "order.created.v1",
+		Trigger:     "/cvxtest/01H0000000000000000000001",
+		Transaction: "01H0000000000000000000002",
+	})
+
+	input, err := message.ToSNS_Input(sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes := make(map[string]interface{})
+	for name, attribute := range input.MessageAttributes {
+		attributes[name] = map[string]interface{}{
+			"Type":  aws.ToString(attribute.DataType),
+			"Value": aws.ToString(attribute.StringValue),
+		}
+	}
+
+	received, err := message.FromSNS(&events.SNSEntity{
+		MessageID:         "01H0000000000000000000003",
+		Type:              "Notification",
+		Subject:           aws.ToString(input.Subject),
+		Message:           aws.ToString(input.Message),
+		Timestamp:         time.Now().UTC(),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received.Trigger() != sent.Trigger() {
+		t.Fatalf("expected trigger `%s`, found `%s`", sent.Trigger(), received.Trigger())
+	}
+	if received.Transaction() != sent.Transaction() {
+		t.Fatalf("expected transaction `%s`, found `%s`", sent.Transaction(), received.Transaction())
+	}
+}
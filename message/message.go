@@ -28,6 +28,12 @@ type Message interface {
 	Transaction() string
 }
 
+// Traced is optionally implemented by messages carrying a W3C trace context
+type Traced interface {
+	TraceParent() string
+	TraceState() string
+}
+
 type Event = Message
 type Command = Message
 
@@ -43,6 +49,8 @@ type messageImpl struct {
 	MessageAuthor       string      `json:"author"`
 	MessageTrigger      string      `json:"trigger"`
 	MessageTransaction  string      `json:"transaction"`
+	MessageTraceParent  string      `json:"traceparent,omitempty"`
+	MessageTraceState   string      `json:"tracestate,omitempty"`
 }
 
 func (c *messageImpl) Source() string {
@@ -95,3 +103,11 @@ func (c *messageImpl) Trigger() string {
 func (c *messageImpl) Transaction() string {
 	return c.MessageTransaction
 }
+
+func (c *messageImpl) TraceParent() string {
+	return c.MessageTraceParent
+}
+
+func (c *messageImpl) TraceState() string {
+	return c.MessageTraceState
+}
@@ -72,5 +72,12 @@ func generateMessageAttributes(msg Message) map[string]types.MessageAttributeVal
 	if msg.Trigger() != "" {
 		attributesMap["trigger"] = newStringMessageAttribute(msg.Trigger())
 	}
+	traceParent, traceState := GetTraceContext(msg)
+	if traceParent != "" {
+		attributesMap["traceparent"] = newStringMessageAttribute(traceParent)
+	}
+	if traceState != "" {
+		attributesMap["tracestate"] = newStringMessageAttribute(traceState)
+	}
 	return attributesMap
 }
@@ -0,0 +1,52 @@
+package message
+
+import (
+	"context"
+
+	"github.com/cevixe/sdk/trace"
+)
+
+func GetTraceContext(msg Message) (traceParent string, traceState string) {
+
+	traced, ok := msg.(Traced)
+	if !ok {
+		return "", ""
+	}
+	return traced.TraceParent(), traced.TraceState()
+}
+
+func WithTraceContext(ctx context.Context, msg Message) Message {
+
+	if traceParent, _ := GetTraceContext(msg); traceParent != "" {
+		return msg
+	}
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return msg
+	}
+
+	impl, ok := msg.(*messageImpl)
+	if !ok {
+		return msg
+	}
+
+	traced := *impl
+	traced.MessageTraceParent = sc.TraceParent()
+	traced.MessageTraceState = sc.TraceState
+	return &traced
+}
+
+func GetSpanContext(msg Message) trace.SpanContext {
+
+	traceParent, traceState := GetTraceContext(msg)
+	if traceParent == "" {
+		return trace.SpanContext{}
+	}
+
+	sc, err := trace.ParseTraceParent(traceParent, traceState)
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	return sc
+}
@@ -0,0 +1,84 @@
+package message_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/trace"
+)
+
+// plainMessage is an external Message implementation without trace context
+type plainMessage struct{}
+
+func (plainMessage) Source() string            { return "/cvxtest/01H0000000000000000000000" }
+func (plainMessage) ID() string                { return "01H0000000000000000000001" }
+func (plainMessage) Kind() message.MessageKind { return message.MessageKind_Command }
+func (plainMessage) Type() string              { return "place-order.v1" }
+func (plainMessage) Time() time.Time           { return time.Now().UTC() }
+func (plainMessage) ContentType() string       { return "application/json" }
+func (plainMessage) EncodingType() string      { return "identity" }
+func (plainMessage) Data(interface{}) error    { return nil }
+func (plainMessage) Author() string            { return "cvxtest" }
+func (plainMessage) Trigger() string           { return "" }
+func (plainMessage) Transaction() string       { return "01H0000000000000000000002" }
+
+const traceParent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+func TestTraceContext_PlainMessage(t *testing.T) {
+
+	sc, err := trace.ParseTraceParent(traceParent, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), sc)
+
+	msg := message.WithTraceContext(ctx, plainMessage{})
+	if _, ok := msg.(plainMessage); !ok {
+		t.Fatalf("expected plain message to be returned unchanged, found %T", msg)
+	}
+	if parent, state := message.GetTraceContext(msg); parent != "" || state != "" {
+		t.Fatalf("expected no trace context, found `%s` `%s`", parent, state)
+	}
+	if message.GetSpanContext(msg).IsValid() {
+		t.Fatal("expected invalid span context")
+	}
+
+	input, err := message.ToSNS_Input(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := input.MessageAttributes["traceparent"]; ok {
+		t.Fatal("unexpected traceparent attribute")
+	}
+}
+
+func TestTraceContext_TracedMessage(t *testing.T) {
+
+	sc, err := trace.ParseTraceParent(traceParent, "vendor=value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), sc)
+
+	msg := message.WithTraceContext(ctx, cvxtest.NewCommand(&cvxtest.MessageProps{Type: "place-order.v1"}))
+	if _, ok := msg.(message.Traced); !ok {
+		t.Fatalf("expected traced message, found %T", msg)
+	}
+	if parent, state := message.GetTraceContext(msg); parent != traceParent || state != "vendor=value" {
+		t.Fatalf("unexpected trace context `%s` `%s`", parent, state)
+	}
+	if message.GetSpanContext(msg) != sc {
+		t.Fatalf("expected span context %v, found %v", sc, message.GetSpanContext(msg))
+	}
+
+	input, err := message.ToSNS_Input(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attribute := input.MessageAttributes["traceparent"]; attribute.StringValue == nil || *attribute.StringValue != traceParent {
+		t.Fatalf("unexpected traceparent attribute %v", attribute.StringValue)
+	}
+}
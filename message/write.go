@@ -8,16 +8,27 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/jsii-runtime-go"
 	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/trace"
 	"github.com/pkg/errors"
 )
 
 func Write(ctx context.Context, msg ...Message) error {
 	cvxini := cvxcontext.GetInitContenxt(ctx)
-	input, err := generateTransactWriteItemsInput(cvxini.AppName, msg...)
+
+	ctx, span := trace.Start(ctx, "dynamodb.TransactWriteItems", "messages", len(msg))
+	defer span.End()
+
+	traced := make([]Message, 0, len(msg))
+	for _, item := range msg {
+		traced = append(traced, WithTraceContext(ctx, item))
+	}
+
+	input, err := generateTransactWriteItemsInput(cvxini.AppName, traced...)
 	if err != nil {
 		return errors.Wrap(err, "cannot generate dynamodb transaction input")
 	}
 	if _, err = cvxini.DynamodbClient.TransactWriteItems(ctx, input); err != nil {
+		span.RecordError(err)
 		return errors.Wrap(err, "cannot execute dynamodb transaction")
 	}
 	return nil
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Unit string
+
+const (
+	Unit_None         Unit = "None"
+	Unit_Count        Unit = "Count"
+	Unit_Milliseconds Unit = "Milliseconds"
+	Unit_Bytes        Unit = "Bytes"
+)
+
+type collectorContextKey string
+
+const currentCollectorKey collectorContextKey = "cvxmetrics"
+
+type Collector interface {
+	SetDimension(name string, value string)
+	Put(name string, value float64, unit Unit)
+	Add(name string, value float64, unit Unit)
+	Flush()
+}
+
+type metricValue struct {
+	Value float64
+	Unit  Unit
+}
+
+type collectorImpl struct {
+	mutex      sync.Mutex
+	namespace  string
+	dimensions map[string]string
+	values     map[string]*metricValue
+	order      []string
+}
+
+var (
+	outputMutex sync.Mutex
+	output      io.Writer = os.Stdout
+)
+
+func SetOutput(writer io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	output = writer
+}
+
+func NewCollector(namespace string) Collector {
+	return &collectorImpl{
+		namespace:  namespace,
+		dimensions: make(map[string]string),
+		values:     make(map[string]*metricValue),
+		order:      make([]string, 0),
+	}
+}
+
+func WithCollector(ctx context.Context, collector Collector) context.Context {
+	return context.WithValue(ctx, currentCollectorKey, collector)
+}
+
+func FromContext(ctx context.Context) Collector {
+	if collector, ok := ctx.Value(currentCollectorKey).(Collector); ok {
+		return collector
+	}
+	return nil
+}
+
+func Add(ctx context.Context, name string, value float64, unit Unit) {
+	if collector := FromContext(ctx); collector != nil {
+		collector.Add(name, value, unit)
+	}
+}
+
+func Put(ctx context.Context, name string, value float64, unit Unit) {
+	if collector := FromContext(ctx); collector != nil {
+		collector.Put(name, value, unit)
+	}
+}
+
+func SetDimension(ctx context.Context, name string, value string) {
+	if collector := FromContext(ctx); collector != nil {
+		collector.SetDimension(name, value)
+	}
+}
+
+func (c *collectorImpl) SetDimension(name string, value string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.dimensions[name] = value
+}
+
+func (c *collectorImpl) Put(name string, value float64, unit Unit) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.values[name]; !ok {
+		c.order = append(c.order, name)
+	}
+	c.values[name] = &metricValue{Value: value, Unit: unit}
+}
+
+func (c *collectorImpl) Add(name string, value float64, unit Unit) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if current, ok := c.values[name]; ok {
+		current.Value += value
+		return
+	}
+	c.order = append(c.order, name)
+	c.values[name] = &metricValue{Value: value, Unit: unit}
+}
+
+func (c *collectorImpl) Flush() {
+	c.mutex.Lock()
+	if len(c.values) == 0 {
+		c.mutex.Unlock()
+		return
+	}
+
+	dimensionNames := make([]string, 0, len(c.dimensions))
+	for name := range c.dimensions {
+		dimensionNames = append(dimensionNames, name)
+	}
+	sort.Strings(dimensionNames)
+
+	definitions := make([]map[string]interface{}, 0, len(c.order))
+	document := make(map[string]interface{})
+	for name, value := range c.dimensions {
+		document[name] = value
+	}
+	for _, name := range c.order {
+		definitions = append(definitions, map[string]interface{}{
+			"Name": name,
+			"Unit": c.values[name].Unit,
+		})
+		document[name] = c.values[name].Value
+	}
+	document["_aws"] = map[string]interface{}{
+		"Timestamp": time.Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]interface{}{
+			{
+				"Namespace":  c.namespace,
+				"Dimensions": [][]string{dimensionNames},
+				"Metrics":    definitions,
+			},
+		},
+	}
+
+	c.values = make(map[string]*metricValue)
+	c.order = make([]string, 0)
+	c.mutex.Unlock()
+
+	buffer, err := json.Marshal(document)
+	if err != nil {
+		return
+	}
+
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	_, _ = output.Write(append(buffer, '\n'))
+}
@@ -0,0 +1,140 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/metrics"
+)
+
+func captureOutput(t *testing.T) *bytes.Buffer {
+	output := &bytes.Buffer{}
+	metrics.SetOutput(output)
+	t.Cleanup(func() { metrics.SetOutput(os.Stdout) })
+	return output
+}
+
+func readDocuments(t *testing.T, output *bytes.Buffer) []map[string]interface{} {
+	documents := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		document := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &document); err != nil {
+			t.Fatalf("invalid emf document `%s`: %v", line, err)
+		}
+		aws := document["_aws"].(map[string]interface{})
+		timestamp, ok := aws["Timestamp"].(float64)
+		if !ok || time.Since(time.UnixMilli(int64(timestamp))) > time.Minute {
+			t.Fatalf("invalid emf timestamp %v", aws["Timestamp"])
+		}
+		delete(aws, "Timestamp")
+		documents = append(documents, document)
+	}
+	return documents
+}
+
+func TestCollector_Flush(t *testing.T) {
+
+	output := captureOutput(t)
+	collector := metrics.NewCollector("cvxtest")
+	collector.Flush()
+	if output.Len() != 0 {
+		t.Fatalf("expected no document without metrics, found `%s`", output.String())
+	}
+
+	collector.SetDimension("Handler", "orders")
+	collector.SetDimension("App", "shop")
+	collector.Put("Duration", 12, metrics.Unit_Milliseconds)
+	collector.Add("Conflicts", 1, metrics.Unit_Count)
+	collector.Add("Conflicts", 2, metrics.Unit_Count)
+	collector.Put("Invocations", 1, metrics.Unit_Count)
+	collector.Put("Invocations", 1, metrics.Unit_Count)
+	collector.Flush()
+
+	collector.SetDimension("Outcome", "error")
+	collector.Put("Invocations", 1, metrics.Unit_Count)
+	collector.Flush()
+	collector.Flush()
+
+	expected := []map[string]interface{}{
+		{
+			"App":         "shop",
+			"Handler":     "orders",
+			"Duration":    12,
+			"Conflicts":   3,
+			"Invocations": 1,
+			"_aws": map[string]interface{}{
+				"CloudWatchMetrics": []interface{}{
+					map[string]interface{}{
+						"Namespace":  "cvxtest",
+						"Dimensions": [][]string{{"App", "Handler"}},
+						"Metrics": []map[string]string{
+							{"Name": "Duration", "Unit": "Milliseconds"},
+							{"Name": "Conflicts", "Unit": "Count"},
+							{"Name": "Invocations", "Unit": "Count"},
+						},
+					},
+				},
+			},
+		},
+		{
+			"App":         "shop",
+			"Handler":     "orders",
+			"Outcome":     "error",
+			"Invocations": 1,
+			"_aws": map[string]interface{}{
+				"CloudWatchMetrics": []interface{}{
+					map[string]interface{}{
+						"Namespace":  "cvxtest",
+						"Dimensions": [][]string{{"App", "Handler", "Outcome"}},
+						"Metrics":    []map[string]string{{"Name": "Invocations", "Unit": "Count"}},
+					},
+				},
+			},
+		},
+	}
+	if diff := cvxtest.DiffJSON(expected, readDocuments(t, output)); diff != "" {
+		t.Fatalf("emf documents mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestContextHelpers(t *testing.T) {
+
+	output := captureOutput(t)
+
+	// helpers are no-ops without a collector
+	metrics.Add(context.Background(), "Conflicts", 1, metrics.Unit_Count)
+	metrics.Put(context.Background(), "Duration", 1, metrics.Unit_Milliseconds)
+	metrics.SetDimension(context.Background(), "Outcome", "error")
+	if metrics.FromContext(context.Background()) != nil {
+		t.Fatal("expected no collector")
+	}
+
+	collector := metrics.NewCollector("cvxtest")
+	ctx := metrics.WithCollector(context.Background(), collector)
+	if metrics.FromContext(ctx) != collector {
+		t.Fatal("expected collector from context")
+	}
+	metrics.SetDimension(ctx, "Outcome", "written")
+	metrics.Add(ctx, "Entities", 2, metrics.Unit_Count)
+	metrics.Add(ctx, "Entities", 1, metrics.Unit_Count)
+	metrics.Put(ctx, "Bytes", 512, metrics.Unit_Bytes)
+	collector.Flush()
+
+	documents := readDocuments(t, output)
+	if len(documents) != 1 {
+		t.Fatalf("expected 1 emf document, found %d", len(documents))
+	}
+	document := documents[0]
+	if document["Outcome"] != "written" || document["Entities"] != float64(3) || document["Bytes"] != float64(512) {
+		t.Fatalf("unexpected emf document %v", document)
+	}
+}
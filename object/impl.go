@@ -5,23 +5,27 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/jsii-runtime-go"
 	"github.com/aws/smithy-go"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/trace"
 )
 
 type clientImpl struct {
 	bucket         *string
-	presignClient  *s3.PresignClient
-	standardClient *s3.Client
+	presignClient  cvxcontext.S3PresignAPI
+	standardClient cvxcontext.S3API
 }
 
 func (c *clientImpl) Exists(
 	ctx context.Context, location string) (*bool, error) {
 
+	ctx, span := trace.Start(ctx, "s3.HeadObject", "bucket", *c.bucket, "key", location)
+	defer span.End()
+
 	_, err := c.standardClient.HeadObject(
 		ctx,
 		&s3.HeadObjectInput{
@@ -31,9 +35,14 @@ func (c *clientImpl) Exists(
 	)
 
 	if err != nil {
+		span.RecordError(err)
 		var ae smithy.APIError
 		if errors.As(err, &ae) {
-			log.Printf("code: %s, message: %s, fault: %s", ae.ErrorCode(), ae.ErrorMessage(), ae.ErrorFault().String())
+			cvxcontext.GetLogger(ctx).Error("cannot validate object existence",
+				"location", location,
+				"code", ae.ErrorCode(),
+				"message", ae.ErrorMessage(),
+				"fault", ae.ErrorFault().String())
 		}
 		return nil, fmt.Errorf("cannot validate existence through headObject: %v", err)
 	}
@@ -44,6 +53,9 @@ func (c *clientImpl) Exists(
 func (c *clientImpl) Content(
 	ctx context.Context, location string) (io.Reader, error) {
 
+	ctx, span := trace.Start(ctx, "s3.GetObject", "bucket", *c.bucket, "key", location)
+	defer span.End()
+
 	output, err := c.standardClient.GetObject(
 		ctx,
 		&s3.GetObjectInput{
@@ -53,6 +65,7 @@ func (c *clientImpl) Content(
 	)
 
 	if err != nil {
+		span.RecordError(err)
 		return nil, fmt.Errorf("cannot obtain content through getObject: %v", err)
 	}
 
@@ -1,14 +1,14 @@
 package object
 
 import (
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/jsii-runtime-go"
+	cvxcontext "github.com/cevixe/sdk/context"
 )
 
 func NewClient(
 	bucket string,
-	presignClient *s3.PresignClient,
-	standardClient *s3.Client,
+	presignClient cvxcontext.S3PresignAPI,
+	standardClient cvxcontext.S3API,
 ) Client {
 
 	return &clientImpl{
@@ -0,0 +1,289 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+)
+
+const tableActiveTimeout = 2 * time.Minute
+
+type DynamoDBAPI interface {
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+}
+
+type ValidationError struct {
+	Table      string
+	Mismatches []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("table %s does not match its definition: %s", e.Table, strings.Join(e.Mismatches, "; "))
+}
+
+func EnsureAll(ctx context.Context, client DynamoDBAPI, definitions ...*TableDefinition) error {
+	for _, definition := range definitions {
+		if err := Ensure(ctx, client, definition); err != nil {
+			return errors.Wrapf(err, "cannot provision table %s", definition.Name)
+		}
+	}
+	return nil
+}
+
+func ValidateAll(ctx context.Context, client DynamoDBAPI, definitions ...*TableDefinition) error {
+	for _, definition := range definitions {
+		if err := Validate(ctx, client, definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Ensure(ctx context.Context, client DynamoDBAPI, definition *TableDefinition) error {
+
+	table, err := describeTable(ctx, client, definition.Name)
+	if err != nil {
+		return err
+	}
+
+	if table == nil {
+		if err = createTable(ctx, client, definition); err != nil {
+			return err
+		}
+	} else {
+		if err = createMissingIndexes(ctx, client, definition, table); err != nil {
+			return err
+		}
+		if err = enableStream(ctx, client, definition, table); err != nil {
+			return err
+		}
+	}
+
+	if definition.TTLAttribute != "" {
+		if err = enableTimeToLive(ctx, client, definition); err != nil {
+			return err
+		}
+	}
+
+	return Validate(ctx, client, definition)
+}
+
+func Validate(ctx context.Context, client DynamoDBAPI, definition *TableDefinition) error {
+
+	table, err := describeTable(ctx, client, definition.Name)
+	if err != nil {
+		return err
+	}
+	if table == nil {
+		return &ValidationError{Table: definition.Name, Mismatches: []string{"table not found"}}
+	}
+
+	mismatches := make([]string, 0)
+	if !equalKeySchema(table.KeySchema, definition.keySchema()) {
+		mismatches = append(mismatches, fmt.Sprintf("key schema expected %s found %s",
+			formatKeySchema(definition.keySchema()), formatKeySchema(table.KeySchema)))
+	}
+
+	indexes := make(map[string]types.GlobalSecondaryIndexDescription)
+	for _, index := range table.GlobalSecondaryIndexes {
+		indexes[aws.ToString(index.IndexName)] = index
+	}
+	for _, index := range definition.Indexes {
+		found, ok := indexes[index.Name]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("index %s not found", index.Name))
+			continue
+		}
+		expected := keySchema(index.PartitionKey, index.SortKey)
+		if !equalKeySchema(found.KeySchema, expected) {
+			mismatches = append(mismatches, fmt.Sprintf("index %s key schema expected %s found %s",
+				index.Name, formatKeySchema(expected), formatKeySchema(found.KeySchema)))
+		}
+	}
+
+	if definition.StreamViewType != "" {
+		stream := table.StreamSpecification
+		if stream == nil || !aws.ToBool(stream.StreamEnabled) {
+			mismatches = append(mismatches, "stream not enabled")
+		} else if stream.StreamViewType != definition.StreamViewType {
+			mismatches = append(mismatches, fmt.Sprintf("stream view type expected %s found %s",
+				definition.StreamViewType, stream.StreamViewType))
+		}
+	}
+
+	if definition.TTLAttribute != "" {
+		output, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+			TableName: aws.String(definition.Name),
+		})
+		if err != nil {
+			return errors.Wrap(err, "cannot describe dynamodb table ttl")
+		}
+		ttl := output.TimeToLiveDescription
+		if ttl == nil ||
+			aws.ToString(ttl.AttributeName) != definition.TTLAttribute ||
+			(ttl.TimeToLiveStatus != types.TimeToLiveStatusEnabled &&
+				ttl.TimeToLiveStatus != types.TimeToLiveStatusEnabling) {
+			mismatches = append(mismatches, fmt.Sprintf("ttl not enabled on %s", definition.TTLAttribute))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return &ValidationError{Table: definition.Name, Mismatches: mismatches}
+	}
+	return nil
+}
+
+func describeTable(ctx context.Context, client DynamoDBAPI, name string) (*types.TableDescription, error) {
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "cannot describe dynamodb table")
+	}
+	return output.Table, nil
+}
+
+func createTable(ctx context.Context, client DynamoDBAPI, definition *TableDefinition) error {
+
+	input := &dynamodb.CreateTableInput{
+		TableName:            aws.String(definition.Name),
+		KeySchema:            definition.keySchema(),
+		AttributeDefinitions: definition.attributeDefinitions(),
+		BillingMode:          types.BillingModePayPerRequest,
+	}
+	for _, index := range definition.Indexes {
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, index.globalSecondaryIndex())
+	}
+	if definition.StreamViewType != "" {
+		input.StreamSpecification = &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: definition.StreamViewType,
+		}
+	}
+
+	if _, err := client.CreateTable(ctx, input); err != nil {
+		return errors.Wrap(err, "cannot create dynamodb table")
+	}
+	return waitTableActive(ctx, client, definition.Name)
+}
+
+func createMissingIndexes(ctx context.Context, client DynamoDBAPI, definition *TableDefinition, table *types.TableDescription) error {
+
+	existing := make(map[string]bool)
+	for _, index := range table.GlobalSecondaryIndexes {
+		existing[aws.ToString(index.IndexName)] = true
+	}
+
+	for _, index := range definition.Indexes {
+		if existing[index.Name] {
+			continue
+		}
+		gsi := index.globalSecondaryIndex()
+		_, err := client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName:            aws.String(definition.Name),
+			AttributeDefinitions: attributeDefinitions(index.PartitionKey, index.SortKey),
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+				{Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName:  gsi.IndexName,
+					KeySchema:  gsi.KeySchema,
+					Projection: gsi.Projection,
+				}},
+			},
+		})
+		if err != nil {
+			return errors.Wrapf(err, "cannot create dynamodb table index %s", index.Name)
+		}
+		if err = waitTableActive(ctx, client, definition.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enableStream only enables a disabled stream; a stream with another view
+// type is left to Validate, as replacing it would break its consumers
+func enableStream(ctx context.Context, client DynamoDBAPI, definition *TableDefinition, table *types.TableDescription) error {
+
+	if definition.StreamViewType == "" {
+		return nil
+	}
+	if stream := table.StreamSpecification; stream != nil && aws.ToBool(stream.StreamEnabled) {
+		return nil
+	}
+
+	_, err := client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName: aws.String(definition.Name),
+		StreamSpecification: &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: definition.StreamViewType,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot enable dynamodb table stream")
+	}
+	return waitTableActive(ctx, client, definition.Name)
+}
+
+func enableTimeToLive(ctx context.Context, client DynamoDBAPI, definition *TableDefinition) error {
+
+	output, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: aws.String(definition.Name),
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot describe dynamodb table ttl")
+	}
+	if ttl := output.TimeToLiveDescription; ttl != nil &&
+		aws.ToString(ttl.AttributeName) == definition.TTLAttribute &&
+		(ttl.TimeToLiveStatus == types.TimeToLiveStatusEnabled ||
+			ttl.TimeToLiveStatus == types.TimeToLiveStatusEnabling) {
+		return nil
+	}
+
+	_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(definition.Name),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(definition.TTLAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot enable dynamodb table ttl")
+	}
+	return nil
+}
+
+func waitTableActive(ctx context.Context, client DynamoDBAPI, name string) error {
+	waiter := dynamodb.NewTableExistsWaiter(client, func(o *dynamodb.TableExistsWaiterOptions) {
+		o.MinDelay = 500 * time.Millisecond
+		o.MaxDelay = 5 * time.Second
+	})
+	err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)}, tableActiveTimeout)
+	if err != nil {
+		return errors.Wrap(err, "dynamodb table not active")
+	}
+	return nil
+}
+
+func equalKeySchema(left []types.KeySchemaElement, right []types.KeySchemaElement) bool {
+	return formatKeySchema(left) == formatKeySchema(right)
+}
+
+func formatKeySchema(schema []types.KeySchemaElement) string {
+	elements := make([]string, 0, len(schema))
+	for _, element := range schema {
+		elements = append(elements, fmt.Sprintf("%s:%s", aws.ToString(element.AttributeName), element.KeyType))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+}
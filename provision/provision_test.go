@@ -0,0 +1,229 @@
+package provision_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cevixe/sdk/provision"
+	"github.com/pkg/errors"
+)
+
+// fakeClient keeps table descriptions in memory and records every change
+type fakeClient struct {
+	mutex   sync.Mutex
+	tables  map[string]*types.TableDescription
+	ttls    map[string]*types.TimeToLiveDescription
+	changes []string
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		tables: make(map[string]*types.TableDescription),
+		ttls:   make(map[string]*types.TimeToLiveDescription),
+	}
+}
+
+func (c *fakeClient) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	table := &types.TableDescription{
+		TableName:           params.TableName,
+		TableStatus:         types.TableStatusActive,
+		KeySchema:           params.KeySchema,
+		StreamSpecification: params.StreamSpecification,
+	}
+	for _, index := range params.GlobalSecondaryIndexes {
+		table.GlobalSecondaryIndexes = append(table.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName: index.IndexName,
+			KeySchema: index.KeySchema,
+		})
+	}
+	c.tables[aws.ToString(params.TableName)] = table
+	c.changes = append(c.changes, "create "+aws.ToString(params.TableName))
+	return &dynamodb.CreateTableOutput{TableDescription: table}, nil
+}
+
+func (c *fakeClient) UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	table, ok := c.tables[aws.ToString(params.TableName)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{}
+	}
+	for _, update := range params.GlobalSecondaryIndexUpdates {
+		if update.Create != nil {
+			table.GlobalSecondaryIndexes = append(table.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+				IndexName: update.Create.IndexName,
+				KeySchema: update.Create.KeySchema,
+			})
+			c.changes = append(c.changes, "create index "+aws.ToString(update.Create.IndexName))
+		}
+	}
+	if params.StreamSpecification != nil {
+		table.StreamSpecification = params.StreamSpecification
+		c.changes = append(c.changes, "enable stream "+string(params.StreamSpecification.StreamViewType))
+	}
+	return &dynamodb.UpdateTableOutput{TableDescription: table}, nil
+}
+
+func (c *fakeClient) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	table, ok := c.tables[aws.ToString(params.TableName)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("table not found")}
+	}
+	return &dynamodb.DescribeTableOutput{Table: table}, nil
+}
+
+func (c *fakeClient) UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.ttls[aws.ToString(params.TableName)] = &types.TimeToLiveDescription{
+		AttributeName:    params.TimeToLiveSpecification.AttributeName,
+		TimeToLiveStatus: types.TimeToLiveStatusEnabling,
+	}
+	c.changes = append(c.changes, "enable ttl "+aws.ToString(params.TimeToLiveSpecification.AttributeName))
+	return &dynamodb.UpdateTimeToLiveOutput{}, nil
+}
+
+func (c *fakeClient) DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	ttl, ok := c.ttls[aws.ToString(params.TableName)]
+	if !ok {
+		ttl = &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: ttl}, nil
+}
+
+func (c *fakeClient) takeChanges() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	changes := c.changes
+	c.changes = nil
+	return changes
+}
+
+func assertChanges(t *testing.T, client *fakeClient, expected ...string) {
+	t.Helper()
+	changes := client.takeChanges()
+	if strings.Join(changes, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected changes %q, found %q", expected, changes)
+	}
+}
+
+func TestEnsure_CreatesTables(t *testing.T) {
+
+	ctx := context.Background()
+	client := newFakeClient()
+	definitions := provision.Tables(&provision.TablesProps{AppName: "shop", DomainName: "sales", Indexes: []string{"owner"}})
+
+	if err := provision.EnsureAll(ctx, client, definitions...); err != nil {
+		t.Fatal(err)
+	}
+	assertChanges(t, client,
+		"create dyn-shop-sales-statestore",
+		"create dyn-shop-sales-dedupstore",
+		"enable ttl expiresAt",
+		"create dyn-shop-core-commandstore",
+		"create dyn-shop-core-eventstore",
+	)
+	if err := provision.ValidateAll(ctx, client, definitions...); err != nil {
+		t.Fatal(err)
+	}
+
+	// ensuring again is a no-op
+	if err := provision.EnsureAll(ctx, client, definitions...); err != nil {
+		t.Fatal(err)
+	}
+	assertChanges(t, client)
+}
+
+func TestEnsure_UpdatesExistingTables(t *testing.T) {
+
+	ctx := context.Background()
+	client := newFakeClient()
+	statestore := provision.StateStore("shop", "sales")
+	eventstore := provision.EventStore("shop")
+	for _, definition := range []*provision.TableDefinition{statestore, eventstore} {
+		withoutStream := *definition
+		withoutStream.StreamViewType = ""
+		if err := provision.Ensure(ctx, client, &withoutStream); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client.takeChanges()
+
+	withIndex := provision.StateStore("shop", "sales", "owner")
+	if err := provision.Validate(ctx, client, withIndex); err == nil {
+		t.Fatal("expected validation error before ensuring the table")
+	}
+	if err := provision.Ensure(ctx, client, withIndex); err != nil {
+		t.Fatal(err)
+	}
+	assertChanges(t, client, "create index owner", "enable stream NEW_AND_OLD_IMAGES")
+
+	if err := provision.Ensure(ctx, client, eventstore); err != nil {
+		t.Fatal(err)
+	}
+	assertChanges(t, client, "enable stream NEW_IMAGE")
+	if err := provision.ValidateAll(ctx, client, withIndex, eventstore); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidate_Mismatches(t *testing.T) {
+
+	ctx := context.Background()
+	client := newFakeClient()
+	definition := provision.StateStore("shop", "sales", "owner")
+
+	var validation *provision.ValidationError
+	err := provision.Validate(ctx, client, definition)
+	if !errors.As(err, &validation) || validation.Mismatches[0] != "table not found" {
+		t.Fatalf("expected table not found, found %v", err)
+	}
+
+	client.tables[definition.Name] = &types.TableDescription{
+		TableName:   aws.String(definition.Name),
+		TableStatus: types.TableStatusActive,
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("version"), KeyType: types.KeyTypeRange},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndexDescription{{
+			IndexName: aws.String("by-space"),
+			KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("__space"), KeyType: types.KeyTypeHash}},
+		}},
+		StreamSpecification: &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: types.StreamViewTypeKeysOnly,
+		},
+	}
+
+	err = provision.Validate(ctx, client, definition)
+	if !errors.As(err, &validation) {
+		t.Fatalf("expected validation error, found %v", err)
+	}
+	expected := []string{
+		"key schema expected [id:HASH] found [id:HASH, version:RANGE]",
+		"index by-space key schema expected [__space:HASH, id:RANGE] found [__space:HASH]",
+		"index owner not found",
+		"stream view type expected NEW_AND_OLD_IMAGES found KEYS_ONLY",
+	}
+	if strings.Join(validation.Mismatches, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected mismatches %q, found %q", expected, validation.Mismatches)
+	}
+
+	// a stream with another view type is reported, never replaced
+	if err = provision.Ensure(ctx, client, definition); !errors.As(err, &validation) {
+		t.Fatalf("expected validation error after ensuring, found %v", err)
+	}
+	assertChanges(t, client, "create index owner")
+}
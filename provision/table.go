@@ -0,0 +1,130 @@
+package provision
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type IndexDefinition struct {
+	Name         string `field:"required"`
+	PartitionKey string `field:"required"`
+	SortKey      string `field:"optional"`
+}
+
+type TableDefinition struct {
+	Name           string               `field:"required"`
+	PartitionKey   string               `field:"required"`
+	SortKey        string               `field:"optional"`
+	Indexes        []*IndexDefinition   `field:"optional"`
+	StreamViewType types.StreamViewType `field:"optional"`
+	TTLAttribute   string               `field:"optional"`
+}
+
+type TablesProps struct {
+	AppName    string   `field:"required"`
+	DomainName string   `field:"required"`
+	Indexes    []string `field:"optional"`
+}
+
+func Tables(props *TablesProps) []*TableDefinition {
+	return []*TableDefinition{
+		StateStore(props.AppName, props.DomainName, props.Indexes...),
+		DedupStore(props.AppName, props.DomainName),
+		CommandStore(props.AppName),
+		EventStore(props.AppName),
+	}
+}
+
+func StateStore(app string, domain string, indexes ...string) *TableDefinition {
+
+	definitions := []*IndexDefinition{
+		{Name: "by-space", PartitionKey: "__space", SortKey: "id"},
+	}
+	for _, index := range indexes {
+		definitions = append(definitions, &IndexDefinition{
+			Name:         index,
+			PartitionKey: fmt.Sprintf("__%s-pk", index),
+			SortKey:      "id",
+		})
+	}
+
+	return &TableDefinition{
+		Name:           fmt.Sprintf("dyn-%s-%s-statestore", app, domain),
+		PartitionKey:   "id",
+		Indexes:        definitions,
+		StreamViewType: types.StreamViewTypeNewAndOldImages,
+	}
+}
+
+func DedupStore(app string, domain string) *TableDefinition {
+	return &TableDefinition{
+		Name:         fmt.Sprintf("dyn-%s-%s-dedupstore", app, domain),
+		PartitionKey: "id",
+		TTLAttribute: "expiresAt",
+	}
+}
+
+func CommandStore(app string) *TableDefinition {
+	return messageStore(app, "command")
+}
+
+func EventStore(app string) *TableDefinition {
+	return messageStore(app, "event")
+}
+
+func messageStore(app string, kind string) *TableDefinition {
+	return &TableDefinition{
+		Name:           fmt.Sprintf("dyn-%s-core-%sstore", app, kind),
+		PartitionKey:   "source",
+		SortKey:        "id",
+		StreamViewType: types.StreamViewTypeNewImage,
+	}
+}
+
+func (d *TableDefinition) keySchema() []types.KeySchemaElement {
+	return keySchema(d.PartitionKey, d.SortKey)
+}
+
+func (d *TableDefinition) attributeDefinitions() []types.AttributeDefinition {
+	names := []string{d.PartitionKey, d.SortKey}
+	for _, index := range d.Indexes {
+		names = append(names, index.PartitionKey, index.SortKey)
+	}
+	return attributeDefinitions(names...)
+}
+
+func (d *IndexDefinition) globalSecondaryIndex() types.GlobalSecondaryIndex {
+	return types.GlobalSecondaryIndex{
+		IndexName:  &d.Name,
+		KeySchema:  keySchema(d.PartitionKey, d.SortKey),
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	}
+}
+
+func keySchema(partitionKey string, sortKey string) []types.KeySchemaElement {
+	schema := []types.KeySchemaElement{
+		{AttributeName: &partitionKey, KeyType: types.KeyTypeHash},
+	}
+	if sortKey != "" {
+		schema = append(schema, types.KeySchemaElement{AttributeName: &sortKey, KeyType: types.KeyTypeRange})
+	}
+	return schema
+}
+
+func attributeDefinitions(names ...string) []types.AttributeDefinition {
+	seen := make(map[string]bool)
+	definitions := make([]types.AttributeDefinition, 0, len(names))
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		attribute := name
+		definitions = append(definitions, types.AttributeDefinition{
+			AttributeName: &attribute,
+			AttributeType: types.ScalarAttributeTypeS,
+		})
+	}
+	return definitions
+}
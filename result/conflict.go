@@ -0,0 +1,93 @@
+package result
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+)
+
+type ConflictError struct {
+	EntityIDs     []string
+	CommandIDs    []string
+	EventIDs      []string
+	Deduplication bool
+	cause         error
+}
+
+func (e *ConflictError) Error() string {
+	conflicts := make([]string, 0)
+	if len(e.EntityIDs) > 0 {
+		conflicts = append(conflicts, fmt.Sprintf("entities [%s]", strings.Join(e.EntityIDs, ", ")))
+	}
+	if len(e.CommandIDs) > 0 {
+		conflicts = append(conflicts, fmt.Sprintf("commands [%s]", strings.Join(e.CommandIDs, ", ")))
+	}
+	if len(e.EventIDs) > 0 {
+		conflicts = append(conflicts, fmt.Sprintf("events [%s]", strings.Join(e.EventIDs, ", ")))
+	}
+	if e.Deduplication {
+		conflicts = append(conflicts, "deduplication record")
+	}
+	return fmt.Sprintf("optimistic concurrency conflict on %s", strings.Join(conflicts, ", "))
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.cause
+}
+
+func IsConflict(err error) bool {
+	var conflict *ConflictError
+	return errors.As(err, &conflict)
+}
+
+type transactItemKind string
+
+const (
+	transactItemKind_Entity        transactItemKind = "entity"
+	transactItemKind_Command       transactItemKind = "command"
+	transactItemKind_Event         transactItemKind = "event"
+	transactItemKind_Deduplication transactItemKind = "deduplication"
+)
+
+type transactItemRef struct {
+	Kind transactItemKind
+	ID   string
+}
+
+func newConflictError(err error, refs []transactItemRef) error {
+
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return nil
+	}
+
+	conflict := &ConflictError{
+		EntityIDs:  make([]string, 0),
+		CommandIDs: make([]string, 0),
+		EventIDs:   make([]string, 0),
+		cause:      err,
+	}
+	found := false
+	for idx, reason := range canceled.CancellationReasons {
+		if reason.Code == nil || *reason.Code != "ConditionalCheckFailed" || idx >= len(refs) {
+			continue
+		}
+		found = true
+		switch refs[idx].Kind {
+		case transactItemKind_Entity:
+			conflict.EntityIDs = append(conflict.EntityIDs, refs[idx].ID)
+		case transactItemKind_Command:
+			conflict.CommandIDs = append(conflict.CommandIDs, refs[idx].ID)
+		case transactItemKind_Event:
+			conflict.EventIDs = append(conflict.EventIDs, refs[idx].ID)
+		case transactItemKind_Deduplication:
+			conflict.Deduplication = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return conflict
+}
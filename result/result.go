@@ -13,16 +13,51 @@ type Result interface {
 	AddCommands(commands ...message.Command) Result
 }
 
+// AggregateResult is implemented by results able to carry aggregate events
+// and snapshots, Write checks for it so custom Result implementations keep
+// working without those methods
+type AggregateResult interface {
+	Result
+
+	GetEvents() []message.Event
+	AddEvents(events ...message.Event) Result
+
+	GetSnapshots() []entity.Entity
+	AddSnapshots(snapshots ...entity.Entity) Result
+}
+
 func NewResult() Result {
+	return newResult()
+}
+
+func Events(result Result) []message.Event {
+	if aggregate, ok := result.(AggregateResult); ok {
+		return aggregate.GetEvents()
+	}
+	return nil
+}
+
+func Snapshots(result Result) []entity.Entity {
+	if aggregate, ok := result.(AggregateResult); ok {
+		return aggregate.GetSnapshots()
+	}
+	return nil
+}
+
+func newResult() *resultImpl {
 	return &resultImpl{
-		entities: make([]entity.Entity, 0),
-		commands: make([]message.Command, 0),
+		entities:  make([]entity.Entity, 0),
+		commands:  make([]message.Command, 0),
+		events:    make([]message.Event, 0),
+		snapshots: make([]entity.Entity, 0),
 	}
 }
 
 type resultImpl struct {
-	entities []entity.Entity
-	commands []message.Command
+	entities  []entity.Entity
+	commands  []message.Command
+	events    []message.Event
+	snapshots []entity.Entity
 }
 
 func (r *resultImpl) GetEntities() []entity.Entity {
@@ -42,3 +77,21 @@ func (r *resultImpl) AddCommands(commands ...message.Command) Result {
 	r.commands = append(r.commands, commands...)
 	return r
 }
+
+func (r *resultImpl) GetEvents() []message.Event {
+	return r.events
+}
+
+func (r *resultImpl) AddEvents(events ...message.Event) Result {
+	r.events = append(r.events, events...)
+	return r
+}
+
+func (r *resultImpl) GetSnapshots() []entity.Entity {
+	return r.snapshots
+}
+
+func (r *resultImpl) AddSnapshots(snapshots ...entity.Entity) Result {
+	r.snapshots = append(r.snapshots, snapshots...)
+	return r
+}
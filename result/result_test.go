@@ -0,0 +1,53 @@
+package result_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+)
+
+type Counter struct {
+	Value int `json:"value"`
+}
+
+func TestWrite_CustomResult(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	var created entity.Entity
+	hdl := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		created = entity.Create(ctx, &Counter{Value: 1}).Execute()
+		res := &cvxtest.ResultStub{}
+		res.AddEntities(created).AddCommands(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "notify.v1"}))
+		return res, nil
+	}
+
+	harness.Invoke(hdl, cvxtest.NewCommand(&cvxtest.MessageProps{Type: "create-counter.v1"})).AssertNoError(t)
+	harness.AssertEntity(t, "Counter", created.ID(), &Counter{Value: 1})
+	harness.AssertCommand(t, "notify.v1")
+}
+
+func TestAggregateResult(t *testing.T) {
+
+	if _, ok := result.NewResult().(result.AggregateResult); !ok {
+		t.Fatalf("expected NewResult to implement AggregateResult")
+	}
+
+	event := cvxtest.NewEvent(&cvxtest.MessageProps{Type: "counter.incremented.v1"})
+	res := result.NewResult()
+	res.(result.AggregateResult).AddEvents(event)
+	if events := result.Events(res); len(events) != 1 || events[0] != event {
+		t.Fatalf("expected result events, found %v", events)
+	}
+	if snapshots := result.Snapshots(res); len(snapshots) != 0 {
+		t.Fatalf("expected no snapshots, found %v", snapshots)
+	}
+
+	custom := &cvxtest.ResultStub{}
+	if result.Events(custom) != nil || result.Snapshots(custom) != nil {
+		t.Fatalf("expected no events or snapshots for a custom result")
+	}
+}
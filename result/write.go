@@ -10,7 +10,10 @@ import (
 	"github.com/aws/jsii-runtime-go"
 	cvxcontext "github.com/cevixe/sdk/context"
 	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/idempotency"
 	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/metrics"
+	"github.com/cevixe/sdk/trace"
 	"github.com/oklog/ulid/v2"
 	"github.com/pkg/errors"
 )
@@ -19,17 +22,84 @@ func Write(ctx context.Context, result Result) error {
 	cvxini := cvxcontext.GetInitContenxt(ctx)
 	statestore := fmt.Sprintf("dyn-%s-%s-statestore", cvxini.AppName, cvxini.DomainName)
 	commandstore := fmt.Sprintf("dyn-%s-core-commandstore", cvxini.AppName)
-	input, err := generateTransactWriteItemsInput(statestore, commandstore, result)
+	eventstore := fmt.Sprintf("dyn-%s-core-eventstore", cvxini.AppName)
+
+	ctx, span := trace.Start(ctx, "dynamodb.TransactWriteItems",
+		"entities", len(result.GetEntities()),
+		"commands", len(result.GetCommands()),
+		"events", len(Events(result)))
+	defer span.End()
+
+	result = withTraceContext(ctx, result)
+	input, err := generateTransactWriteItemsInput(statestore, commandstore, eventstore, result)
 	if err != nil {
 		return errors.Wrap(err, "cannot generate dynamodb transaction input")
 	}
-	if _, err = cvxini.DynamodbClient.TransactWriteItems(ctx, input); err != nil {
+	refs := generateTransactItemRefs(result)
+	if dedup := idempotency.GenerateTransactPut(ctx); dedup != nil {
+		input.TransactItems = append(input.TransactItems, *dedup)
+		refs = append(refs, transactItemRef{Kind: transactItemKind_Deduplication})
+	}
+	if len(input.TransactItems) == 0 {
+		return nil
+	}
+	log := cvxcontext.GetLogger(ctx)
+	log.Debug("executing dynamodb transaction", "items", len(input.TransactItems))
+	input.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	output, err := cvxini.DynamodbClient.TransactWriteItems(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		if conflict := newConflictError(err, refs); conflict != nil {
+			log.Warn("dynamodb transaction conflict", "error", conflict)
+			return conflict
+		}
+		log.Error("cannot execute dynamodb transaction", "error", err)
 		return errors.Wrap(err, "cannot execute dynamodb transaction")
 	}
+	metrics.Add(ctx, "TransactionItems", float64(len(input.TransactItems)), metrics.Unit_Count)
+	for _, capacity := range output.ConsumedCapacity {
+		if capacity.CapacityUnits != nil {
+			metrics.Add(ctx, "ConsumedCapacity", *capacity.CapacityUnits, metrics.Unit_Count)
+		}
+	}
 	return nil
 }
 
-func generateTransactWriteItemsInput(statestore string, commandstore string, result Result) (*dynamodb.TransactWriteItemsInput, error) {
+func withTraceContext(ctx context.Context, result Result) Result {
+	commands := make([]message.Command, 0, len(result.GetCommands()))
+	for _, item := range result.GetCommands() {
+		commands = append(commands, message.WithTraceContext(ctx, item))
+	}
+	events := make([]message.Event, 0, len(Events(result)))
+	for _, item := range Events(result) {
+		events = append(events, message.WithTraceContext(ctx, item))
+	}
+	traced := newResult()
+	traced.AddEntities(result.GetEntities()...)
+	traced.AddCommands(commands...)
+	traced.AddEvents(events...)
+	traced.AddSnapshots(Snapshots(result)...)
+	return traced
+}
+
+func generateTransactItemRefs(result Result) []transactItemRef {
+	refs := make([]transactItemRef, 0)
+	for _, item := range result.GetEntities() {
+		refs = append(refs, transactItemRef{Kind: transactItemKind_Entity, ID: item.ID()})
+	}
+	for _, item := range result.GetCommands() {
+		refs = append(refs, transactItemRef{Kind: transactItemKind_Command, ID: fmt.Sprintf("%s/%s", item.Source(), item.ID())})
+	}
+	for _, item := range Events(result) {
+		refs = append(refs, transactItemRef{Kind: transactItemKind_Event, ID: fmt.Sprintf("%s/%s", item.Source(), item.ID())})
+	}
+	for _, item := range Snapshots(result) {
+		refs = append(refs, transactItemRef{Kind: transactItemKind_Entity, ID: item.ID()})
+	}
+	return refs
+}
+
+func generateTransactWriteItemsInput(statestore string, commandstore string, eventstore string, result Result) (*dynamodb.TransactWriteItemsInput, error) {
 	items := make([]types.TransactWriteItem, 0)
 
 	for _, item := range result.GetEntities() {
@@ -62,6 +132,22 @@ func generateTransactWriteItemsInput(statestore string, commandstore string, res
 		items = append(items, *insert)
 	}
 
+	for _, item := range Events(result) {
+		insert, err := generateTransactMessageInsert(eventstore, item)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot generate transact event insert")
+		}
+		items = append(items, *insert)
+	}
+
+	for _, item := range Snapshots(result) {
+		put, err := generateTransactSnapshotPut(statestore, item)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot generate transact snapshot put")
+		}
+		items = append(items, *put)
+	}
+
 	return &dynamodb.TransactWriteItemsInput{
 		TransactItems: items,
 	}, nil
@@ -127,6 +213,37 @@ func generateTransactEntityInsert(table string, input entity.Entity) (*types.Tra
 	}, nil
 }
 
+func generateTransactSnapshotPut(table string, input entity.Entity) (*types.TransactWriteItem, error) {
+	item, err := entity.ToDynamodb_Map(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal snapshot to dynamodb map")
+	}
+	for key, value := range item {
+		if value == nil {
+			delete(item, key)
+		} else if _, ok := value.(*types.AttributeValueMemberNULL); ok {
+			delete(item, key)
+		}
+	}
+
+	return &types.TransactWriteItem{
+		Put: &types.Put{
+			TableName:           jsii.String(table),
+			Item:                item,
+			ConditionExpression: jsii.String("attribute_not_exists(#id) OR (#snapshot = :snapshot AND #version < :version)"),
+			ExpressionAttributeNames: map[string]string{
+				"#id":       "id",
+				"#snapshot": "__snapshot",
+				"#version":  "version",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":snapshot": &types.AttributeValueMemberBOOL{Value: true},
+				":version":  &types.AttributeValueMemberN{Value: strconv.FormatUint(input.Version(), 10)},
+			},
+		},
+	}, nil
+}
+
 func generateTransactEntityUpdate(table string, input entity.Entity) (*types.TransactWriteItem, error) {
 	item, err := entity.ToDynamodb_Map(input)
 	if err != nil {
@@ -143,7 +260,6 @@ func generateTransactEntityUpdate(table string, input entity.Entity) (*types.Tra
 	propsToAvoid := map[string]bool{
 		"__typename": true,
 		"id":         true,
-		"version":    true,
 		"__status":   true,
 		"__space":    true,
 		"createdAt":  true,
@@ -232,6 +348,7 @@ func generateTransactEntityDelete(table string, input entity.Entity) (*types.Tra
 	}
 
 	fieldsToUpdate := []string{
+		"version",
 		"updatedAt",
 		"updatedBy",
 		"__status",
@@ -241,6 +358,8 @@ func generateTransactEntityDelete(table string, input entity.Entity) (*types.Tra
 		"__eventversion",
 		"__eventtrigger",
 		"__eventdata",
+		"__traceparent",
+		"__tracestate",
 	}
 
 	for _, field := range fieldsToUpdate {
@@ -0,0 +1,79 @@
+package result_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/cevixe/sdk/runtime"
+)
+
+func TestWrite_PersistsEntityVersion(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	counter := harness.NewEntity(&Counter{})
+	if err := harness.Seed(counter); err != nil {
+		t.Fatal(err)
+	}
+
+	increment := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		current, err := entity.FindOne(ctx, &entity.FindOneProps{Domain: "cvxtest", Typename: "Counter", ID: counter.ID()})
+		if err != nil {
+			return nil, err
+		}
+		state := &Counter{}
+		if err = current.Data(state); err != nil {
+			return nil, err
+		}
+		state.Value++
+		return result.NewResult().AddEntities(current.Mutate(ctx, state).Execute()), nil
+	}
+	remove := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		current, err := entity.FindOne(ctx, &entity.FindOneProps{Domain: "cvxtest", Typename: "Counter", ID: counter.ID()})
+		if err != nil {
+			return nil, err
+		}
+		return result.NewResult().AddEntities(current.Delete(ctx).Execute()), nil
+	}
+
+	command := cvxtest.NewCommand(&cvxtest.MessageProps{Type: "increment-counter.v1"})
+	for expected := uint64(2); expected <= 3; expected++ {
+		harness.Invoke(increment, command, runtime.WithConflictRetries(0)).AssertNoError(t)
+		stored := harness.AssertEntity(t, "Counter", counter.ID(), map[string]interface{}{"value": expected - 1})
+		if stored.Version() != expected {
+			t.Fatalf("expected stored version %d, found %d", expected, stored.Version())
+		}
+	}
+
+	harness.Invoke(remove, command, runtime.WithConflictRetries(0)).AssertNoError(t)
+	stored, err := harness.FindEntity("Counter", counter.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Version() != 4 || stored.Status() != entity.EntityStatus_Dead {
+		t.Fatalf("expected dead entity at version 4, found %s at version %d", stored.Status(), stored.Version())
+	}
+}
+
+func TestWrite_RejectsStaleVersion(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	counter := harness.NewEntity(&Counter{})
+	if err := harness.Seed(counter); err != nil {
+		t.Fatal(err)
+	}
+
+	// every invocation mutates the seeded image, so all but the first are stale
+	increment := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		return result.NewResult().AddEntities(counter.Mutate(ctx, &Counter{Value: 1}).Execute()), nil
+	}
+
+	command := cvxtest.NewCommand(&cvxtest.MessageProps{Type: "increment-counter.v1"})
+	harness.Invoke(increment, command, runtime.WithConflictRetries(0)).AssertNoError(t)
+	if err := harness.Invoke(increment, command, runtime.WithConflictRetries(0)).AssertError(t); !result.IsConflict(err) {
+		t.Fatalf("expected conflict on stale version, found %v", err)
+	}
+}
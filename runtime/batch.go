@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/handler"
+	"github.com/cevixe/sdk/message"
+)
+
+const maxConcurrentGroups = 10
+
+func processSQSBatch(ctx context.Context, o *options, hdl handler.Handler, records []events.SQSMessage) events.SQSEventResponse {
+
+	groups := groupSQSRecords(records)
+	failures := make([][]events.SQSBatchItemFailure, len(groups))
+
+	wg := &sync.WaitGroup{}
+	wg.Add(len(groups))
+	semaphore := make(chan struct{}, maxConcurrentGroups)
+	for idx, group := range groups {
+		semaphore <- struct{}{}
+		go func(idx int, group []events.SQSMessage) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			failures[idx] = processSQSGroup(ctx, o, hdl, group)
+		}(idx, group)
+	}
+	wg.Wait()
+
+	response := events.SQSEventResponse{
+		BatchItemFailures: make([]events.SQSBatchItemFailure, 0),
+	}
+	for _, item := range failures {
+		response.BatchItemFailures = append(response.BatchItemFailures, item...)
+	}
+	return response
+}
+
+func processSQSGroup(ctx context.Context, o *options, hdl handler.Handler, group []events.SQSMessage) []events.SQSBatchItemFailure {
+
+	failures := make([]events.SQSBatchItemFailure, 0)
+	for idx, record := range group {
+		if err := processSQSRecord(ctx, o, hdl, record); err != nil {
+			// messages of the same group must be retried in order
+			for _, pending := range group[idx:] {
+				failures = append(failures, events.SQSBatchItemFailure{
+					ItemIdentifier: pending.MessageId,
+				})
+			}
+			break
+		}
+	}
+	return failures
+}
+
+func processSQSRecord(ctx context.Context, o *options, hdl handler.Handler, record events.SQSMessage) error {
+
+	msg, err := message.FromSQS(record)
+	if err != nil {
+		cvxcontext.GetLogger(ctx).Error("cannot read sqs message", "messageId", record.MessageId, "error", err)
+		return err
+	}
+	return processMessage(ctx, o, hdl, msg)
+}
+
+func groupSQSRecords(records []events.SQSMessage) [][]events.SQSMessage {
+
+	groups := make([][]events.SQSMessage, 0)
+	positions := make(map[string]int)
+	for _, record := range records {
+		groupID := record.Attributes["MessageGroupId"]
+		if groupID == "" {
+			groups = append(groups, []events.SQSMessage{record})
+			continue
+		}
+		if position, ok := positions[groupID]; ok {
+			groups[position] = append(groups[position], record)
+			continue
+		}
+		positions[groupID] = len(groups)
+		groups = append(groups, []events.SQSMessage{record})
+	}
+	return groups
+}
@@ -0,0 +1,163 @@
+package runtime_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/cevixe/sdk/runtime"
+	"github.com/pkg/errors"
+)
+
+type groupItem struct {
+	Group string `json:"group"`
+	Seq   int    `json:"seq"`
+}
+
+func newSNSEntity(t *testing.T, msg message.Message) events.SNSEntity {
+	input, err := message.ToSNS_Input(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes := make(map[string]interface{})
+	for name, attribute := range input.MessageAttributes {
+		attributes[name] = map[string]interface{}{
+			"Type":  aws.ToString(attribute.DataType),
+			"Value": aws.ToString(attribute.StringValue),
+		}
+	}
+	return events.SNSEntity{
+		MessageID:         msg.ID(),
+		Type:              "Notification",
+		Subject:           aws.ToString(input.Subject),
+		Message:           aws.ToString(input.Message),
+		Timestamp:         time.Now().UTC(),
+		MessageAttributes: attributes,
+	}
+}
+
+func newSQSRecord(t *testing.T, group string, seq int) events.SQSMessage {
+	msg := cvxtest.NewCommand(&cvxtest.MessageProps{
+		Type: "process-item.v1",
+		Data: &groupItem{Group: group, Seq: seq},
+	})
+	body, err := json.Marshal(newSNSEntity(t, msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := events.SQSMessage{
+		MessageId:  fmt.Sprintf("%s%d", group, seq),
+		Body:       string(body),
+		Attributes: map[string]string{},
+	}
+	if group != "" {
+		record.Attributes["MessageGroupId"] = group
+	}
+	return record
+}
+
+func wrapSQSHandler(t *testing.T, hdl func(ctx context.Context, msg message.Message) (result.Result, error)) func(context.Context, events.SQSEvent) (events.SQSEventResponse, error) {
+	t.Setenv("CVX_HANDLER_MODE", "advanced")
+	return runtime.WrapHandler(hdl, runtime.WithMetrics("")).(func(context.Context, events.SQSEvent) (events.SQSEventResponse, error))
+}
+
+func TestSQSBatch_InterleavedGroups(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	mutex := &sync.Mutex{}
+	processed := make(map[string][]int)
+	lambda := wrapSQSHandler(t, func(ctx context.Context, msg message.Message) (result.Result, error) {
+		item := &groupItem{}
+		if err := msg.Data(item); err != nil {
+			return nil, err
+		}
+		mutex.Lock()
+		processed[item.Group] = append(processed[item.Group], item.Seq)
+		mutex.Unlock()
+		if item.Group == "B" && item.Seq == 2 {
+			return nil, errors.New("cannot process item")
+		}
+		return nil, nil
+	})
+
+	records := []events.SQSMessage{
+		newSQSRecord(t, "A", 1),
+		newSQSRecord(t, "B", 1),
+		newSQSRecord(t, "A", 2),
+		newSQSRecord(t, "C", 1),
+		newSQSRecord(t, "B", 2),
+		newSQSRecord(t, "", 1),
+		newSQSRecord(t, "A", 3),
+		newSQSRecord(t, "B", 3),
+		newSQSRecord(t, "C", 2),
+	}
+	malformed := events.SQSMessage{MessageId: "D1", Body: "{", Attributes: map[string]string{"MessageGroupId": "D"}}
+	records = append(records, malformed, newSQSRecord(t, "D", 2))
+
+	response, err := lambda(harness.Context(), events.SQSEvent{Records: records})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// failed records and the rest of their group are retried in order
+	expectedFailures := []events.SQSBatchItemFailure{
+		{ItemIdentifier: "B2"},
+		{ItemIdentifier: "B3"},
+		{ItemIdentifier: "D1"},
+		{ItemIdentifier: "D2"},
+	}
+	if diff := cvxtest.DiffJSON(expectedFailures, response.BatchItemFailures); diff != "" {
+		t.Fatalf("batch item failures mismatch (-expected +actual):\n%s", diff)
+	}
+	expectedOrder := map[string][]int{
+		"A": {1, 2, 3},
+		"B": {1, 2},
+		"C": {1, 2},
+		"":  {1},
+	}
+	if diff := cvxtest.DiffJSON(expectedOrder, processed); diff != "" {
+		t.Fatalf("group order mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestSQSBatch_MaxConcurrentGroups(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	var running, peak int32
+	lambda := wrapSQSHandler(t, func(ctx context.Context, msg message.Message) (result.Result, error) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			observed := atomic.LoadInt32(&peak)
+			if current <= observed || atomic.CompareAndSwapInt32(&peak, observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return nil, nil
+	})
+
+	records := make([]events.SQSMessage, 0)
+	for group := 0; group < 3*runtime.MaxConcurrentGroups; group++ {
+		records = append(records, newSQSRecord(t, fmt.Sprintf("G%d-", group), 1))
+	}
+	response, err := lambda(harness.Context(), events.SQSEvent{Records: records})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.BatchItemFailures) != 0 {
+		t.Fatalf("unexpected batch item failures %v", response.BatchItemFailures)
+	}
+	if peak > runtime.MaxConcurrentGroups || peak < 2 {
+		t.Fatalf("expected between 2 and %d concurrent groups, found %d", runtime.MaxConcurrentGroups, peak)
+	}
+}
@@ -4,11 +4,9 @@ import (
 	"context"
 	"os"
 
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/cevixe/sdk/client/config"
 	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/logger"
 )
 
 func NewContext() context.Context {
@@ -16,21 +14,17 @@ func NewContext() context.Context {
 	ctx := context.Background()
 	cfg := config.NewConfig(ctx)
 
-	appName := os.Getenv("CVX_APP_NAME")
-	domainName := os.Getenv("CVX_DOMAIN_NAME")
-	handlerName := os.Getenv("CVX_HANDLER_NAME")
-	s3Client := s3.NewFromConfig(cfg)
-	snsClient := sns.NewFromConfig(cfg)
-	dynamodbClient := dynamodb.NewFromConfig(cfg)
+	return cvxcontext.NewInitContext(ctx,
+		&cvxcontext.InitContextProps{
+			AppName:        os.Getenv("CVX_APP_NAME"),
+			DomainName:     os.Getenv("CVX_DOMAIN_NAME"),
+			HandlerName:    os.Getenv("CVX_HANDLER_NAME"),
+			S3Client:       config.NewS3Client(cfg),
+			SNSClient:      config.NewSNSClient(cfg),
+			DynamodbClient: config.NewDynamoDBClient(cfg),
+			Logger:         logger.Default(),
 
-	ctx = context.WithValue(ctx, cvxcontext.CevixeInitContextKey,
-		&cvxcontext.InitContext{
-			AppName:        appName,
-			DomainName:     domainName,
-			HandlerName:    handlerName,
-			S3Client:       s3Client,
-			SNSClient:      snsClient,
-			DynamodbClient: dynamodbClient,
+			PageTokenSigningKey:    os.Getenv("CVX_PAGE_TOKEN_SIGNING_KEY"),
+			PageTokenEncryptionKey: os.Getenv("CVX_PAGE_TOKEN_ENCRYPTION_KEY"),
 		})
-	return ctx
 }
@@ -0,0 +1,119 @@
+package runtime_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/cevixe/sdk/runtime"
+)
+
+const dedupstore = "dyn-cvxtest-cvxtest-dedupstore"
+
+func TestDeduplication(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		handler  func(ctx context.Context, msg message.Message) (result.Result, error)
+		entities int
+	}{
+		{
+			name: "written result",
+			handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+				return result.NewResult().AddEntities(entity.Create(ctx, &Counter{Value: 1}).Execute()), nil
+			},
+			entities: 1,
+		},
+		{
+			name: "empty result",
+			handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+				return result.NewResult(), nil
+			},
+		},
+		{
+			name: "nil result",
+			handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+				return nil, nil
+			},
+		},
+		{
+			name: "handler writes itself",
+			handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+				own := result.NewResult().AddEntities(entity.Create(ctx, &Counter{Value: 1}).Execute())
+				if err := result.Write(ctx, own); err != nil {
+					return nil, err
+				}
+				return result.NewResult().AddEntities(entity.Create(ctx, &Counter{Value: 2}).Execute()), nil
+			},
+			entities: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			harness := cvxtest.NewHarness(nil)
+			calls := 0
+			hdl := func(ctx context.Context, msg message.Message) (result.Result, error) {
+				calls++
+				return test.handler(ctx, msg)
+			}
+
+			command := cvxtest.NewCommand(&cvxtest.MessageProps{Type: "increment-counter.v1"})
+			for i := 0; i < 2; i++ {
+				harness.Invoke(hdl, command, runtime.WithDeduplication(time.Hour)).AssertNoError(t)
+			}
+			if calls != 1 {
+				t.Fatalf("expected 1 handler call, found %d", calls)
+			}
+			if records := harness.Store.Items(dedupstore); len(records) != 1 {
+				t.Fatalf("expected 1 deduplication record, found %d", len(records))
+			}
+			counters, err := harness.Entities("Counter")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(counters) != test.entities {
+				t.Fatalf("expected %d stored entities, found %d", test.entities, len(counters))
+			}
+		})
+	}
+}
+
+func TestDeduplication_ExpiredRecord(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	command := cvxtest.NewCommand(&cvxtest.MessageProps{Type: "increment-counter.v1"})
+	expired := time.Now().Add(-time.Minute).Unix()
+	err := harness.Store.Put(dedupstore, map[string]types.AttributeValue{
+		"id":        &types.AttributeValueMemberS{Value: "cvxtest#" + command.Source() + "/" + command.ID()},
+		"expiresAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(expired, 10)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	hdl := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		calls++
+		return result.NewResult().AddEntities(entity.Create(ctx, &Counter{Value: 1}).Execute()), nil
+	}
+	harness.Invoke(hdl, command, runtime.WithDeduplication(time.Hour)).AssertNoError(t)
+	if calls != 1 {
+		t.Fatalf("expected expired record to be ignored, found %d handler calls", calls)
+	}
+
+	records := harness.Store.Items(dedupstore)
+	if len(records) != 1 {
+		t.Fatalf("expected 1 deduplication record, found %d", len(records))
+	}
+	expiresAt, ok := records[0]["expiresAt"].(*types.AttributeValueMemberN)
+	if !ok || expiresAt.Value == strconv.FormatInt(expired, 10) {
+		t.Fatal("expected expired record to be replaced")
+	}
+}
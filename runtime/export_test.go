@@ -0,0 +1,22 @@
+package runtime
+
+import (
+	"io"
+	"net/http"
+)
+
+var ConflictBackoff = conflictBackoff
+
+const MaxConcurrentGroups = maxConcurrentGroups
+
+var DetectEventMode = detectEventMode
+var NewLocalRunner = newLocalRunner
+var NewLocalEntityChangeRunner = newLocalEntityChangeRunner
+
+func (r *localRunner) Read(input io.Reader, output io.Writer) error {
+	return r.read(input, output)
+}
+
+func (r *localRunner) Mux() http.Handler {
+	return r.mux()
+}
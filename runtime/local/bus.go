@@ -0,0 +1,248 @@
+package local
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/handler"
+	"github.com/cevixe/sdk/internal/memstore"
+	"github.com/cevixe/sdk/logger"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/runtime"
+	"github.com/pkg/errors"
+)
+
+var ErrMaxDeliveries = errors.New("maximum number of deliveries reached")
+
+type BusProps struct {
+	AppName        string                 `field:"optional"`
+	DomainName     string                 `field:"optional"`
+	DynamodbClient cvxcontext.DynamoDBAPI `field:"optional"`
+	Logger         logger.Logger          `field:"optional"`
+	MaxDeliveries  int                    `field:"optional"`
+	Options        []runtime.Option       `field:"optional"`
+}
+
+type Subscription struct {
+	Name    string              `field:"required"`
+	Domain  string              `field:"optional"`
+	Kind    message.MessageKind `field:"optional"`
+	Filters []string            `field:"optional"`
+	Handler handler.Handler     `field:"required"`
+	Options []runtime.Option    `field:"optional"`
+}
+
+type Delivery struct {
+	Subscription string
+	Message      message.Message
+	Err          error
+}
+
+type Bus interface {
+	Subscribe(subscription *Subscription) Bus
+	Publish(msg ...message.Message) Bus
+	Run(ctx context.Context) error
+	Deliveries() []*Delivery
+	Failures() []*Delivery
+	Published() []message.Message
+	Context(domain string) context.Context
+}
+
+func NewBus(props *BusProps) Bus {
+
+	if props == nil {
+		props = &BusProps{}
+	}
+	client := props.DynamodbClient
+	if client == nil {
+		client = memstore.NewStore()
+	}
+	log := props.Logger
+	if log == nil {
+		log = logger.NewJSONLogger(io.Discard, logger.Level_Error)
+	}
+	maxDeliveries := props.MaxDeliveries
+	if maxDeliveries == 0 {
+		maxDeliveries = 1000
+	}
+
+	bus := &busImpl{
+		appName:       defaultString(props.AppName, "local"),
+		domainName:    defaultString(props.DomainName, "local"),
+		logger:        log,
+		maxDeliveries: maxDeliveries,
+		options:       props.Options,
+		subscriptions: make([]*Subscription, 0),
+		queue:         make([]message.Message, 0),
+		published:     make([]message.Message, 0),
+		deliveries:    make([]*Delivery, 0),
+	}
+	bus.client = &streamClient{DynamoDBAPI: client, bus: bus}
+	return bus
+}
+
+type busImpl struct {
+	appName       string
+	domainName    string
+	client        cvxcontext.DynamoDBAPI
+	logger        logger.Logger
+	maxDeliveries int
+	options       []runtime.Option
+	subscriptions []*Subscription
+	mutex         sync.Mutex
+	queue         []message.Message
+	published     []message.Message
+	deliveries    []*Delivery
+}
+
+func (b *busImpl) Subscribe(subscription *Subscription) Bus {
+	b.subscriptions = append(b.subscriptions, subscription)
+	return b
+}
+
+func (b *busImpl) Publish(msg ...message.Message) Bus {
+	for _, item := range msg {
+		b.enqueue(item)
+	}
+	return b
+}
+
+func (b *busImpl) Run(ctx context.Context) error {
+
+	delivered := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "local bus run canceled")
+		}
+
+		msg, ok := b.dequeue()
+		if !ok {
+			break
+		}
+
+		for _, subscription := range b.subscriptions {
+			if !matchSubscription(subscription, msg) {
+				continue
+			}
+			if delivered >= b.maxDeliveries {
+				return errors.Wrapf(ErrMaxDeliveries, "local bus stopped after %d deliveries", delivered)
+			}
+			delivered++
+			b.dispatch(ctx, subscription, msg)
+		}
+	}
+
+	if failures := b.Failures(); len(failures) > 0 {
+		return errors.Wrapf(failures[0].Err, "%d of %d deliveries failed, first on `%s` handling `%s`",
+			len(failures), len(b.Deliveries()), failures[0].Subscription, failures[0].Message.Type())
+	}
+	return nil
+}
+
+func (b *busImpl) dispatch(ctx context.Context, subscription *Subscription, msg message.Message) {
+
+	delivery := &Delivery{Subscription: subscription.Name, Message: msg}
+	defer func() {
+		b.mutex.Lock()
+		b.deliveries = append(b.deliveries, delivery)
+		b.mutex.Unlock()
+	}()
+
+	received, err := deliver(b.appName, subscription.Name, msg)
+	if err != nil {
+		delivery.Err = errors.Wrap(err, "cannot deliver message through sns/sqs envelope")
+		return
+	}
+	delivery.Message = received
+
+	opts := append([]runtime.Option{runtime.WithMetrics("")}, b.options...)
+	opts = append(opts, subscription.Options...)
+	handlerContext := b.handlerContext(ctx, subscription)
+	delivery.Err = runtime.Execute(handlerContext, subscription.Handler, received, opts...)
+	if delivery.Err != nil {
+		b.logger.Warn("local delivery failed",
+			"subscription", subscription.Name,
+			"type", received.Type(),
+			"error", delivery.Err)
+	}
+}
+
+func (b *busImpl) Deliveries() []*Delivery {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]*Delivery{}, b.deliveries...)
+}
+
+func (b *busImpl) Failures() []*Delivery {
+	failures := make([]*Delivery, 0)
+	for _, delivery := range b.Deliveries() {
+		if delivery.Err != nil {
+			failures = append(failures, delivery)
+		}
+	}
+	return failures
+}
+
+func (b *busImpl) Published() []message.Message {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]message.Message{}, b.published...)
+}
+
+func (b *busImpl) Context(domain string) context.Context {
+	return b.newContext(context.Background(), defaultString(domain, b.domainName), "local")
+}
+
+func (b *busImpl) handlerContext(ctx context.Context, subscription *Subscription) context.Context {
+	return b.newContext(ctx, defaultString(subscription.Domain, b.domainName), subscription.Name)
+}
+
+func (b *busImpl) newContext(ctx context.Context, domain string, handlerName string) context.Context {
+	return cvxcontext.NewInitContext(ctx,
+		&cvxcontext.InitContextProps{
+			AppName:        b.appName,
+			DomainName:     domain,
+			HandlerName:    handlerName,
+			DynamodbClient: b.client,
+			Logger:         b.logger,
+		})
+}
+
+func (b *busImpl) enqueue(msg message.Message) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.queue = append(b.queue, msg)
+	b.published = append(b.published, msg)
+}
+
+func (b *busImpl) dequeue() (message.Message, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.queue) == 0 {
+		return nil, false
+	}
+	msg := b.queue[0]
+	b.queue = b.queue[1:]
+	return msg, true
+}
+
+func matchSubscription(subscription *Subscription, msg message.Message) bool {
+	if len(subscription.Filters) == 0 {
+		return handler.MatchRoute(&handler.Route{Kind: subscription.Kind}, msg)
+	}
+	for _, filter := range subscription.Filters {
+		if handler.MatchRoute(&handler.Route{Kind: subscription.Kind, Pattern: filter}, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultString(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,143 @@
+package local_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/cevixe/sdk/runtime/local"
+	"github.com/pkg/errors"
+)
+
+type Order struct {
+	Name    string `json:"name"`
+	Shipped bool   `json:"shipped"`
+}
+
+func placeOrder(ctx context.Context, msg message.Message) (result.Result, error) {
+	order := &Order{}
+	if err := msg.Data(order); err != nil {
+		return nil, err
+	}
+	return result.NewResult().AddEntities(entity.Create(ctx, order).Execute()), nil
+}
+
+func requestShipping(ctx context.Context, msg message.Message) (result.Result, error) {
+	command := cvxtest.NewCommand(&cvxtest.MessageProps{
+		Type: "ship-order.v1",
+		Data: map[string]string{"id": msg.Source()[len("/order/"):]},
+	})
+	return result.NewResult().AddCommands(command), nil
+}
+
+func shipOrder(ctx context.Context, msg message.Message) (result.Result, error) {
+	request := make(map[string]string)
+	if err := msg.Data(&request); err != nil {
+		return nil, err
+	}
+	current, err := entity.FindOne(ctx, &entity.FindOneProps{Domain: "local", Typename: "Order", ID: request["id"]})
+	if err != nil {
+		return nil, err
+	}
+	order := &Order{}
+	if err = current.Data(order); err != nil {
+		return nil, err
+	}
+	order.Shipped = true
+	return result.NewResult().AddEntities(current.Mutate(ctx, order).SetEvent("shipped", 2, nil).Execute()), nil
+}
+
+type delivery struct {
+	Subscription string `json:"subscription"`
+	Type         string `json:"type"`
+}
+
+func deliveries(bus local.Bus) []delivery {
+	items := make([]delivery, 0)
+	for _, item := range bus.Deliveries() {
+		items = append(items, delivery{Subscription: item.Subscription, Type: item.Message.Type()})
+	}
+	return items
+}
+
+func TestBus_PublishSubscribe(t *testing.T) {
+
+	bus := local.NewBus(nil).
+		Subscribe(&local.Subscription{Name: "orders", Kind: message.MessageKind_Command, Filters: []string{"place-order"}, Handler: placeOrder}).
+		Subscribe(&local.Subscription{Name: "shipping", Kind: message.MessageKind_Event, Filters: []string{"order.created.v1"}, Handler: requestShipping}).
+		Subscribe(&local.Subscription{Name: "warehouse", Filters: []string{"ship-order.v*"}, Handler: shipOrder}).
+		Subscribe(&local.Subscription{Name: "audit", Kind: message.MessageKind_Event, Handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+			return nil, nil
+		}})
+
+	bus.Publish(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "place-order.v1", Data: &Order{Name: "book"}}))
+	if err := bus.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []delivery{
+		{Subscription: "orders", Type: "place-order.v1"},
+		{Subscription: "shipping", Type: "order.created.v1"},
+		{Subscription: "audit", Type: "order.created.v1"},
+		{Subscription: "warehouse", Type: "ship-order.v1"},
+		{Subscription: "audit", Type: "order.shipped.v2"},
+	}
+	if diff := cvxtest.DiffJSON(expected, deliveries(bus)); diff != "" {
+		t.Fatalf("deliveries mismatch (-expected +actual):\n%s", diff)
+	}
+
+	published := make([]string, 0)
+	for _, msg := range bus.Published() {
+		published = append(published, msg.Type())
+	}
+	expectedPublished := []string{"place-order.v1", "order.created.v1", "ship-order.v1", "order.shipped.v2"}
+	if diff := cvxtest.DiffJSON(expectedPublished, published); diff != "" {
+		t.Fatalf("published mismatch (-expected +actual):\n%s", diff)
+	}
+	if failures := bus.Failures(); len(failures) != 0 {
+		t.Fatalf("unexpected failures %v", failures)
+	}
+}
+
+func TestBus_Failures(t *testing.T) {
+
+	failure := errors.New("cannot place order")
+	bus := local.NewBus(nil).
+		Subscribe(&local.Subscription{Name: "orders", Handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+			return nil, failure
+		}}).
+		Subscribe(&local.Subscription{Name: "audit", Handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+			return nil, nil
+		}})
+
+	bus.Publish(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "place-order.v1"}))
+	if err := bus.Run(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("expected delivery failure, found %v", err)
+	}
+	failures := bus.Failures()
+	if len(failures) != 1 || failures[0].Subscription != "orders" {
+		t.Fatalf("expected a single failure on `orders`, found %v", failures)
+	}
+	if len(bus.Deliveries()) != 2 {
+		t.Fatalf("expected the other subscription to keep receiving, found %d deliveries", len(bus.Deliveries()))
+	}
+}
+
+func TestBus_MaxDeliveries(t *testing.T) {
+
+	bus := local.NewBus(&local.BusProps{MaxDeliveries: 5}).
+		Subscribe(&local.Subscription{Name: "echo", Handler: func(ctx context.Context, msg message.Message) (result.Result, error) {
+			return result.NewResult().AddCommands(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "echo.v1"})), nil
+		}})
+
+	bus.Publish(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "echo.v1"}))
+	if err := bus.Run(context.Background()); !errors.Is(err, local.ErrMaxDeliveries) {
+		t.Fatalf("expected maximum deliveries error, found %v", err)
+	}
+	if len(bus.Deliveries()) != 5 {
+		t.Fatalf("expected 5 deliveries, found %d", len(bus.Deliveries()))
+	}
+}
@@ -0,0 +1,113 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/pkg/errors"
+)
+
+type streamClient struct {
+	cvxcontext.DynamoDBAPI
+	bus *busImpl
+}
+
+func (c *streamClient) TransactWriteItems(
+	ctx context.Context,
+	params *dynamodb.TransactWriteItemsInput,
+	optFns ...func(*dynamodb.Options),
+) (*dynamodb.TransactWriteItemsOutput, error) {
+
+	output, err := c.DynamoDBAPI.TransactWriteItems(ctx, params, optFns...)
+	if err != nil {
+		return output, err
+	}
+
+	for _, item := range params.TransactItems {
+		if err = c.capture(ctx, item); err != nil {
+			return output, errors.Wrap(err, "cannot emulate dynamodb stream")
+		}
+	}
+	return output, nil
+}
+
+func (c *streamClient) capture(ctx context.Context, item types.TransactWriteItem) error {
+
+	switch {
+	case item.Put != nil:
+		table := aws.ToString(item.Put.TableName)
+		switch {
+		case strings.HasSuffix(table, "-statestore"):
+			return c.captureEntity(ctx, table, map[string]types.AttributeValue{"id": item.Put.Item["id"]})
+		case strings.HasSuffix(table, "-commandstore"),
+			strings.HasSuffix(table, "-eventstore"):
+			msg, err := message.FromDynamodb_TableMap(item.Put.Item)
+			if err != nil {
+				return errors.Wrap(err, "cannot read stored message")
+			}
+			c.bus.enqueue(msg)
+		}
+	case item.Update != nil:
+		table := aws.ToString(item.Update.TableName)
+		if strings.HasSuffix(table, "-statestore") {
+			return c.captureEntity(ctx, table, item.Update.Key)
+		}
+	}
+	return nil
+}
+
+func (c *streamClient) captureEntity(ctx context.Context, table string, key map[string]types.AttributeValue) error {
+
+	output, err := c.DynamoDBAPI.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(table),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot read entity new image")
+	}
+	if output.Item == nil {
+		return nil
+	}
+	if _, ok := output.Item["__snapshot"]; ok {
+		return nil
+	}
+
+	value, err := entity.FromDynamodb_TableMap(output.Item)
+	if err != nil {
+		return errors.Wrap(err, "cannot read entity new image")
+	}
+	event, err := value.LastEvent()
+	if err != nil {
+		return errors.Wrap(err, "cannot generate entity last event")
+	}
+
+	eventstore := fmt.Sprintf("dyn-%s-core-eventstore", c.bus.appName)
+	eventMap, err := message.ToDynamodb_Map(event)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal entity last event")
+	}
+	for name, attribute := range eventMap {
+		if _, ok := attribute.(*types.AttributeValueMemberNULL); ok || attribute == nil {
+			delete(eventMap, name)
+		}
+	}
+	_, err = c.DynamoDBAPI.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{TableName: aws.String(eventstore), Item: eventMap}},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot store entity last event")
+	}
+
+	c.bus.enqueue(event)
+	return nil
+}
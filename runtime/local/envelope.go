@@ -0,0 +1,75 @@
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cevixe/sdk/message"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+)
+
+func toSNSEntity(topic string, msg message.Message) (*events.SNSEntity, error) {
+
+	input, err := message.ToSNS_Input(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot generate sns input")
+	}
+
+	attributes := make(map[string]interface{})
+	for name, attribute := range input.MessageAttributes {
+		attributes[name] = map[string]interface{}{
+			"Type":  aws.ToString(attribute.DataType),
+			"Value": aws.ToString(attribute.StringValue),
+		}
+	}
+
+	return &events.SNSEntity{
+		MessageID:         ulid.Make().String(),
+		Type:              "Notification",
+		TopicArn:          topic,
+		Subject:           aws.ToString(input.Subject),
+		Message:           aws.ToString(input.Message),
+		Timestamp:         time.Now().UTC(),
+		MessageAttributes: attributes,
+	}, nil
+}
+
+func toSQSMessage(queue string, entity *events.SNSEntity, groupID string) (events.SQSMessage, error) {
+
+	body, err := json.Marshal(entity)
+	if err != nil {
+		return events.SQSMessage{}, errors.Wrap(err, "cannot marshal sns notification")
+	}
+
+	return events.SQSMessage{
+		MessageId:      ulid.Make().String(),
+		Body:           string(body),
+		EventSource:    "aws:sqs",
+		EventSourceARN: queue,
+		Attributes: map[string]string{
+			"MessageGroupId":          groupID,
+			"ApproximateReceiveCount": "1",
+		},
+	}, nil
+}
+
+func deliver(app string, name string, msg message.Message) (message.Message, error) {
+
+	topic := fmt.Sprintf("arn:aws:sns:local:000000000000:sns-%s-core-%sbus", app, msg.Kind())
+	notification, err := toSNSEntity(topic, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := fmt.Sprintf("arn:aws:sqs:local:000000000000:sqs-%s-%s", app, name)
+	record, err := toSQSMessage(queue, notification, msg.Source())
+	if err != nil {
+		return nil, err
+	}
+
+	return message.FromSQS(record)
+}
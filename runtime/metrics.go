@@ -0,0 +1,41 @@
+package runtime
+
+import (
+	"context"
+	"time"
+
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/metrics"
+)
+
+func startMetrics(ctx context.Context, o *options, msg message.Message) (context.Context, metrics.Collector) {
+
+	if o.metricsNamespace == "" {
+		return ctx, nil
+	}
+
+	collector := metrics.NewCollector(o.metricsNamespace)
+	if cvxini, ok := ctx.Value(cvxcontext.CevixeInitContextKey).(*cvxcontext.InitContext); ok && cvxini != nil {
+		collector.SetDimension("App", cvxini.AppName)
+		collector.SetDimension("Domain", cvxini.DomainName)
+		collector.SetDimension("Handler", cvxini.HandlerName)
+	}
+	collector.SetDimension("MessageType", msg.Type())
+
+	return metrics.WithCollector(ctx, collector), collector
+}
+
+func finishMetrics(collector metrics.Collector, start time.Time, err error) {
+
+	if collector == nil {
+		return
+	}
+
+	if err != nil {
+		collector.SetDimension("Outcome", "error")
+	}
+	collector.Put("Duration", float64(time.Since(start).Milliseconds()), metrics.Unit_Milliseconds)
+	collector.Put("Invocations", 1, metrics.Unit_Count)
+	collector.Flush()
+}
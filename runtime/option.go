@@ -0,0 +1,88 @@
+package runtime
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cevixe/sdk/handler"
+)
+
+type Option func(*options)
+
+type options struct {
+	middlewares      []handler.Middleware
+	deduplicationTTL time.Duration
+	conflictRetries  int
+	metricsNamespace string
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		middlewares:      make([]handler.Middleware, 0),
+		deduplicationTTL: getEnvDuration("CVX_HANDLER_DEDUPLICATION_TTL"),
+		conflictRetries:  getEnvInt("CVX_HANDLER_CONFLICT_RETRIES", 3),
+		metricsNamespace: getEnvString("CVX_HANDLER_METRICS_NAMESPACE", "Cevixe"),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func WithMiddleware(middlewares ...handler.Middleware) Option {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+func WithDeduplication(ttl time.Duration) Option {
+	return func(o *options) {
+		o.deduplicationTTL = ttl
+	}
+}
+
+func WithConflictRetries(retries int) Option {
+	return func(o *options) {
+		o.conflictRetries = retries
+	}
+}
+
+func WithMetrics(namespace string) Option {
+	return func(o *options) {
+		o.metricsNamespace = namespace
+	}
+}
+
+func getEnvString(name string, defaultValue string) string {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(name string) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("invalid %s duration, %v", name, err)
+	}
+	return duration
+}
+
+func getEnvInt(name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("invalid %s number, %v", name, err)
+	}
+	return number
+}
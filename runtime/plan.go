@@ -0,0 +1,134 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/pkg/errors"
+)
+
+type PlanItem struct {
+	Operation                 string                 `json:"operation"`
+	Table                     string                 `json:"table"`
+	Key                       map[string]interface{} `json:"key,omitempty"`
+	Item                      map[string]interface{} `json:"item,omitempty"`
+	UpdateExpression          string                 `json:"updateExpression,omitempty"`
+	ConditionExpression       string                 `json:"conditionExpression,omitempty"`
+	ExpressionAttributeNames  map[string]string      `json:"expressionAttributeNames,omitempty"`
+	ExpressionAttributeValues map[string]interface{} `json:"expressionAttributeValues,omitempty"`
+}
+
+type Plan struct {
+	Transactions [][]*PlanItem `json:"transactions"`
+	DryRun       bool          `json:"dryRun"`
+}
+
+type planClient struct {
+	cvxcontext.DynamoDBAPI
+	dryRun       bool
+	mutex        sync.Mutex
+	transactions [][]*PlanItem
+}
+
+func (c *planClient) TransactWriteItems(
+	ctx context.Context,
+	params *dynamodb.TransactWriteItemsInput,
+	optFns ...func(*dynamodb.Options),
+) (*dynamodb.TransactWriteItemsOutput, error) {
+
+	transaction, err := newPlanTransaction(params)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot generate transaction plan")
+	}
+
+	c.mutex.Lock()
+	c.transactions = append(c.transactions, transaction)
+	c.mutex.Unlock()
+
+	if c.dryRun {
+		return &dynamodb.TransactWriteItemsOutput{}, nil
+	}
+	return c.DynamoDBAPI.TransactWriteItems(ctx, params, optFns...)
+}
+
+func (c *planClient) flush() *Plan {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	plan := &Plan{Transactions: c.transactions, DryRun: c.dryRun}
+	if plan.Transactions == nil {
+		plan.Transactions = make([][]*PlanItem, 0)
+	}
+	c.transactions = nil
+	return plan
+}
+
+func newPlanTransaction(params *dynamodb.TransactWriteItemsInput) ([]*PlanItem, error) {
+
+	transaction := make([]*PlanItem, 0, len(params.TransactItems))
+	for _, item := range params.TransactItems {
+		var planItem *PlanItem
+		var err error
+		switch {
+		case item.Put != nil:
+			planItem, err = newPlanItem("Put", item.Put.TableName, nil, item.Put.Item, nil,
+				item.Put.ConditionExpression, item.Put.ExpressionAttributeNames, item.Put.ExpressionAttributeValues)
+		case item.Update != nil:
+			planItem, err = newPlanItem("Update", item.Update.TableName, item.Update.Key, nil, item.Update.UpdateExpression,
+				item.Update.ConditionExpression, item.Update.ExpressionAttributeNames, item.Update.ExpressionAttributeValues)
+		case item.Delete != nil:
+			planItem, err = newPlanItem("Delete", item.Delete.TableName, item.Delete.Key, nil, nil,
+				item.Delete.ConditionExpression, item.Delete.ExpressionAttributeNames, item.Delete.ExpressionAttributeValues)
+		case item.ConditionCheck != nil:
+			planItem, err = newPlanItem("ConditionCheck", item.ConditionCheck.TableName, item.ConditionCheck.Key, nil, nil,
+				item.ConditionCheck.ConditionExpression, item.ConditionCheck.ExpressionAttributeNames, item.ConditionCheck.ExpressionAttributeValues)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		transaction = append(transaction, planItem)
+	}
+	return transaction, nil
+}
+
+func newPlanItem(
+	operation string,
+	table *string,
+	key map[string]types.AttributeValue,
+	item map[string]types.AttributeValue,
+	updateExpression *string,
+	conditionExpression *string,
+	names map[string]string,
+	values map[string]types.AttributeValue,
+) (*PlanItem, error) {
+
+	planItem := &PlanItem{
+		Operation:                operation,
+		Table:                    aws.ToString(table),
+		UpdateExpression:         aws.ToString(updateExpression),
+		ConditionExpression:      aws.ToString(conditionExpression),
+		ExpressionAttributeNames: names,
+	}
+	if key != nil {
+		if err := attributevalue.UnmarshalMap(key, &planItem.Key); err != nil {
+			return nil, errors.Wrap(err, "cannot unmarshal plan item key")
+		}
+	}
+	if item != nil {
+		if err := attributevalue.UnmarshalMap(item, &planItem.Item); err != nil {
+			return nil, errors.Wrap(err, "cannot unmarshal plan item")
+		}
+	}
+	if values != nil {
+		if err := attributevalue.UnmarshalMap(values, &planItem.ExpressionAttributeValues); err != nil {
+			return nil, errors.Wrap(err, "cannot unmarshal plan item values")
+		}
+	}
+	return planItem, nil
+}
@@ -0,0 +1,75 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/metrics"
+	"github.com/cevixe/sdk/result"
+	"github.com/cevixe/sdk/trace"
+	"github.com/pkg/errors"
+)
+
+const (
+	conflictBackoffBase = 50 * time.Millisecond
+	conflictBackoffMax  = 2 * time.Second
+)
+
+func execute(ctx context.Context, o *options, msg message.Message, run func(ctx context.Context) (result.Result, error)) error {
+
+	ctx = trace.ContextWithRemoteSpanContext(ctx, message.GetSpanContext(msg))
+	ctx, span := trace.Start(ctx, fmt.Sprintf("handle %s", msg.Type()),
+		"messaging.kind", string(msg.Kind()),
+		"messaging.source", msg.Source(),
+		"messaging.id", msg.ID(),
+		"messaging.transaction", msg.Transaction())
+	defer span.End()
+
+	ctx, collector := startMetrics(ctx, o, msg)
+	start := time.Now()
+
+	for attempt := 0; ; attempt++ {
+
+		span.SetAttributes("attempts", attempt+1)
+		err := executeAttempt(ctx, o, msg, run)
+		if err != nil && result.IsConflict(err) {
+			metrics.Add(ctx, "Conflicts", 1, metrics.Unit_Count)
+		}
+		if err == nil || !result.IsConflict(err) || attempt >= o.conflictRetries {
+			span.RecordError(err)
+			finishMetrics(collector, start, err)
+			return err
+		}
+
+		backoff := conflictBackoff(attempt)
+		cvxcontext.GetLogger(ctx).Warn("retrying message handler after conflict",
+			"trigger", fmt.Sprintf("%s/%s", msg.Source(), msg.ID()),
+			"transaction", msg.Transaction(),
+			"attempt", attempt+1,
+			"retries", o.conflictRetries,
+			"backoff", backoff,
+			"error", err)
+
+		select {
+		case <-ctx.Done():
+			err = errors.Wrap(err, "message handler retry canceled")
+			span.RecordError(err)
+			finishMetrics(collector, start, err)
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func conflictBackoff(attempt int) time.Duration {
+	ceiling := conflictBackoffBase << attempt
+	if ceiling <= 0 || ceiling > conflictBackoffMax {
+		ceiling = conflictBackoffMax
+	}
+	// full jitter spreads concurrent writers of the same entities
+	return time.Duration(rand.Int63n(int64(ceiling))) + time.Millisecond
+}
@@ -0,0 +1,184 @@
+package runtime_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/metrics"
+	"github.com/cevixe/sdk/result"
+	"github.com/cevixe/sdk/runtime"
+	"github.com/cevixe/sdk/trace"
+	"github.com/pkg/errors"
+)
+
+type Counter struct {
+	Value int `json:"value"`
+}
+
+// incrementWithRivals returns a handler that increments the counter, letting a
+// rival writer commit first on the given number of attempts
+func incrementWithRivals(harness *cvxtest.Harness, id string, rivals int32, attempts *int32) func(ctx context.Context, msg message.Message) (result.Result, error) {
+	return func(ctx context.Context, msg message.Message) (result.Result, error) {
+		attempt := atomic.AddInt32(attempts, 1)
+		current, err := entity.FindOne(ctx, &entity.FindOneProps{Domain: "cvxtest", Typename: "Counter", ID: id})
+		if err != nil {
+			return nil, err
+		}
+		state := &Counter{}
+		if err = current.Data(state); err != nil {
+			return nil, err
+		}
+		if attempt <= rivals {
+			rival := current.Mutate(ctx, &Counter{Value: state.Value + 100}).Execute()
+			if err = harness.Seed(rival); err != nil {
+				return nil, err
+			}
+		}
+		state.Value++
+		return result.NewResult().AddEntities(current.Mutate(ctx, state).Execute()), nil
+	}
+}
+
+func TestExecute_ConflictRetries(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		retries  int
+		rivals   int32
+		attempts int32
+		conflict bool
+		value    int
+	}{
+		{name: "no conflict", retries: 3, rivals: 0, attempts: 1, value: 1},
+		{name: "conflict resolved on retry with fresh reads", retries: 3, rivals: 2, attempts: 3, value: 201},
+		{name: "retries exhausted", retries: 2, rivals: 10, attempts: 3, conflict: true, value: 300},
+		{name: "retries disabled", retries: 0, rivals: 1, attempts: 1, conflict: true, value: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			harness := cvxtest.NewHarness(nil)
+			counter := harness.NewEntity(&Counter{})
+			if err := harness.Seed(counter); err != nil {
+				t.Fatal(err)
+			}
+
+			var attempts int32
+			hdl := incrementWithRivals(harness, counter.ID(), test.rivals, &attempts)
+			invocation := harness.Invoke(hdl, cvxtest.NewCommand(&cvxtest.MessageProps{Type: "increment.v1"}),
+				runtime.WithConflictRetries(test.retries))
+
+			if attempts != test.attempts {
+				t.Fatalf("expected %d attempts, found %d", test.attempts, attempts)
+			}
+			if test.conflict {
+				err := invocation.AssertError(t)
+				conflict := &result.ConflictError{}
+				if !errors.As(err, &conflict) {
+					t.Fatalf("expected conflict error, found %v", err)
+				}
+				if len(conflict.EntityIDs) != 1 || conflict.EntityIDs[0] != counter.ID() {
+					t.Fatalf("expected conflict on entity %s, found %v", counter.ID(), conflict.EntityIDs)
+				}
+			} else {
+				invocation.AssertNoError(t)
+			}
+			harness.AssertEntity(t, "Counter", counter.ID(), &Counter{Value: test.value})
+		})
+	}
+}
+
+func TestExecute_DoesNotRetryOtherErrors(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	failure := errors.New("validation failed")
+	var attempts int32
+	hdl := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		atomic.AddInt32(&attempts, 1)
+		return nil, failure
+	}
+
+	err := harness.Invoke(hdl, cvxtest.NewCommand(&cvxtest.MessageProps{Type: "validate.v1"}),
+		runtime.WithConflictRetries(3)).AssertError(t)
+	if !errors.Is(err, failure) || result.IsConflict(err) {
+		t.Fatalf("expected handler error, found %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected a single attempt, found %d", attempts)
+	}
+}
+
+func TestExecute_RetryCanceled(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	counter := harness.NewEntity(&Counter{})
+	if err := harness.Seed(counter); err != nil {
+		t.Fatal(err)
+	}
+
+	var attempts int32
+	hdl := incrementWithRivals(harness, counter.ID(), 100, &attempts)
+	ctx, cancel := context.WithCancel(harness.Context())
+	canceling := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		defer cancel()
+		return hdl(ctx, msg)
+	}
+
+	recorder := trace.NewRecorder()
+	trace.SetExporter(recorder)
+	defer trace.SetExporter(nil)
+	output := &bytes.Buffer{}
+	metrics.SetOutput(output)
+	defer metrics.SetOutput(os.Stdout)
+
+	err := runtime.Execute(ctx, canceling, cvxtest.NewCommand(&cvxtest.MessageProps{Type: "increment.v1"}),
+		runtime.WithMetrics("cvxtest"), runtime.WithConflictRetries(100))
+	if err == nil || !result.IsConflict(err) {
+		t.Fatalf("expected conflict error after cancellation, found %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected retries to stop after cancellation, found %d attempts", attempts)
+	}
+
+	document := make(map[string]interface{})
+	if err = json.Unmarshal(output.Bytes(), &document); err != nil {
+		t.Fatalf("expected flushed metrics, found `%s`: %v", output.String(), err)
+	}
+	if document["Outcome"] != "error" || document["Invocations"] != float64(1) {
+		t.Fatalf("expected failed invocation metrics, found %v", document)
+	}
+	for _, span := range recorder.Spans() {
+		if span.Name == "handle increment.v1" {
+			if !strings.Contains(span.Error, "message handler retry canceled") {
+				t.Fatalf("expected canceled retry error on handler span, found `%s`", span.Error)
+			}
+			return
+		}
+	}
+	t.Fatal("expected handler span")
+}
+
+func TestConflictBackoff(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		ceiling := 50 * time.Millisecond << attempt
+		if ceiling > 2*time.Second {
+			ceiling = 2 * time.Second
+		}
+		for sample := 0; sample < 100; sample++ {
+			backoff := runtime.ConflictBackoff(attempt)
+			if backoff < time.Millisecond || backoff > ceiling+time.Millisecond {
+				t.Fatalf("attempt %d backoff %v outside [1ms, %v]", attempt, backoff, ceiling+time.Millisecond)
+			}
+		}
+	}
+}
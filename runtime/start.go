@@ -1,12 +1,28 @@
 package runtime
 
 import (
+	"os"
+
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/cevixe/sdk/handler"
 )
 
-func Start(hdl handler.Handler) {
+func Start(hdl handler.Handler, opts ...Option) {
+	if os.Getenv("CVX_RUNTIME") == "local" {
+		StartLocal(hdl, opts...)
+		return
+	}
+	ctx := NewContext()
+	lmb := WrapHandler(hdl, opts...)
+	lambda.StartWithOptions(lmb, lambda.WithContext(ctx))
+}
+
+func StartEntityChange(hdl handler.EntityChangeHandler, opts ...Option) {
+	if os.Getenv("CVX_RUNTIME") == "local" {
+		StartLocalEntityChange(hdl, opts...)
+		return
+	}
 	ctx := NewContext()
-	lmb := WrapHandler(hdl)
+	lmb := WrapEntityChangeHandler(hdl, opts...)
 	lambda.StartWithOptions(lmb, lambda.WithContext(ctx))
 }
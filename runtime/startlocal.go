@@ -0,0 +1,177 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/handler"
+	"github.com/pkg/errors"
+)
+
+type localResult struct {
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Plan     *Plan           `json:"plan"`
+}
+
+type localRunner struct {
+	ctx     context.Context
+	wrap    func(mode string) interface{}
+	planner *planClient
+	mutex   sync.Mutex
+}
+
+func StartLocal(hdl handler.Handler, opts ...Option) {
+	startLocal(newLocalRunner(NewContext(), hdl, opts...))
+}
+
+func StartLocalEntityChange(hdl handler.EntityChangeHandler, opts ...Option) {
+	startLocal(newLocalEntityChangeRunner(NewContext(), hdl, opts...))
+}
+
+func startLocal(runner *localRunner) {
+
+	if address := os.Getenv("CVX_LOCAL_ADDRESS"); address != "" {
+		log.Fatalln(runner.serve(address))
+		return
+	}
+	if err := runner.read(os.Stdin, os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func newLocalRunner(ctx context.Context, hdl handler.Handler, opts ...Option) *localRunner {
+
+	o := newOptions(opts...)
+	chained := handler.Chain(hdl, o.middlewares...)
+	return newLocalRunnerWith(ctx, func(mode string) interface{} {
+		return wrapHandler(o, chained, mode)
+	})
+}
+
+func newLocalEntityChangeRunner(ctx context.Context, hdl handler.EntityChangeHandler, opts ...Option) *localRunner {
+
+	lmb := WrapEntityChangeHandler(hdl, opts...)
+	return newLocalRunnerWith(ctx, func(mode string) interface{} {
+		// entity change handlers only react to statestore streams
+		if mode != "stream" {
+			return nil
+		}
+		return lmb
+	})
+}
+
+func newLocalRunnerWith(ctx context.Context, wrap func(mode string) interface{}) *localRunner {
+
+	cvxini := cvxcontext.GetInitContenxt(ctx)
+	planner := &planClient{
+		DynamoDBAPI: cvxini.DynamodbClient,
+		dryRun:      getEnvString("CVX_LOCAL_DRY_RUN", "false") == "true",
+	}
+	cvxini.DynamodbClient = planner
+
+	return &localRunner{
+		ctx:     ctx,
+		wrap:    wrap,
+		planner: planner,
+	}
+}
+
+func (r *localRunner) read(input io.Reader, output io.Writer) error {
+
+	decoder := json.NewDecoder(input)
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	for {
+		var payload json.RawMessage
+		if err := decoder.Decode(&payload); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "cannot read local event")
+		}
+		if err := encoder.Encode(r.invoke(payload)); err != nil {
+			return errors.Wrap(err, "cannot write local result")
+		}
+	}
+}
+
+func (r *localRunner) serve(address string) error {
+
+	cvxcontext.GetLogger(r.ctx).Info("local runtime listening", "address", address)
+	return http.ListenAndServe(address, r.mux())
+}
+
+func (r *localRunner) mux() http.Handler {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		payload, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, "cannot read local event", http.StatusBadRequest)
+			return
+		}
+		res := r.invoke(payload)
+		w.Header().Set("Content-Type", "application/json")
+		if res.Error != "" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+		json.NewEncoder(w).Encode(res)
+	})
+	return mux
+}
+
+func (r *localRunner) invoke(payload []byte) *localResult {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	res := &localResult{}
+	mode := getEnvString("CVX_HANDLER_MODE", detectEventMode(payload))
+	lmb := r.wrap(mode)
+	if lmb == nil {
+		res.Error = "handler execution mode not found"
+		res.Plan = r.planner.flush()
+		return res
+	}
+
+	response, err := lambda.NewHandler(lmb).Invoke(r.ctx, payload)
+	if err != nil {
+		res.Error = err.Error()
+	} else if len(response) > 0 && string(response) != "null" {
+		res.Response = response
+	}
+	res.Plan = r.planner.flush()
+	return res
+}
+
+func detectEventMode(payload []byte) string {
+
+	event := struct {
+		Records []map[string]json.RawMessage `json:"Records"`
+	}{}
+	if err := json.Unmarshal(payload, &event); err != nil || len(event.Records) == 0 {
+		return ""
+	}
+	record := event.Records[0]
+	switch {
+	case record["Sns"] != nil:
+		return "basic"
+	case record["body"] != nil:
+		return "standard"
+	case record["dynamodb"] != nil:
+		return "stream"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,238 @@
+package runtime_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/cevixe/sdk/runtime"
+	"github.com/pkg/errors"
+)
+
+type localResponse struct {
+	Response json.RawMessage `json:"response"`
+	Error    string          `json:"error"`
+	Plan     *runtime.Plan   `json:"plan"`
+}
+
+func createCounter(ctx context.Context, msg message.Message) (result.Result, error) {
+	counter := &Counter{}
+	if err := msg.Data(counter); err != nil {
+		return nil, err
+	}
+	if counter.Value < 0 {
+		return nil, errors.New("negative counter")
+	}
+	return result.NewResult().AddEntities(entity.Create(ctx, counter).Execute()), nil
+}
+
+func newSNSPayload(t *testing.T, value int) []byte {
+	msg := cvxtest.NewCommand(&cvxtest.MessageProps{Type: "create-counter.v1", Data: &Counter{Value: value}})
+	payload, err := json.Marshal(&events.SNSEvent{Records: []events.SNSEventRecord{{SNS: newSNSEntity(t, msg)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func newSQSPayload(t *testing.T) []byte {
+	payload, err := json.Marshal(&events.SQSEvent{Records: []events.SQSMessage{newSQSRecord(t, "A", 1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestDetectEventMode(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		payload  string
+		expected string
+	}{
+		{name: "sns", payload: `{"Records":[{"Sns":{}}]}`, expected: "basic"},
+		{name: "sqs", payload: `{"Records":[{"body":"{}"}]}`, expected: "standard"},
+		{name: "dynamodb stream", payload: `{"Records":[{"dynamodb":{}}]}`, expected: "stream"},
+		{name: "unknown record", payload: `{"Records":[{"kinesis":{}}]}`},
+		{name: "no records", payload: `{"Records":[]}`},
+		{name: "not an event", payload: `{"id":"1"}`},
+		{name: "invalid json", payload: `{`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if mode := runtime.DetectEventMode([]byte(test.payload)); mode != test.expected {
+				t.Fatalf("expected mode `%s`, found `%s`", test.expected, mode)
+			}
+		})
+	}
+}
+
+func TestLocalRunner_Read(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	runner := runtime.NewLocalRunner(harness.Context(), createCounter, runtime.WithMetrics(""))
+
+	input := bytes.NewBuffer(nil)
+	input.Write(newSNSPayload(t, 1))
+	input.WriteString("\n")
+	input.Write(newSNSPayload(t, -1))
+	input.WriteString(`{"id":"1"}`)
+	output := bytes.NewBuffer(nil)
+	if err := runner.Read(input, output); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder := json.NewDecoder(output)
+	results := make([]*localResponse, 0)
+	for {
+		res := &localResponse{}
+		if err := decoder.Decode(res); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, res)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, found %d", len(results))
+	}
+	if results[0].Error != "" || len(results[0].Plan.Transactions) != 1 || results[0].Plan.DryRun {
+		t.Fatalf("expected one written transaction, found %+v", results[0])
+	}
+	if results[1].Error == "" || len(results[1].Plan.Transactions) != 0 {
+		t.Fatalf("expected handler error without transactions, found %+v", results[1])
+	}
+	if results[2].Error != "handler execution mode not found" {
+		t.Fatalf("expected unknown event error, found %+v", results[2])
+	}
+	if counters, err := harness.Entities("Counter"); err != nil || len(counters) != 1 {
+		t.Fatalf("expected 1 stored counter, found %d: %v", len(counters), err)
+	}
+
+	if err := runner.Read(bytes.NewBufferString("{"), io.Discard); err == nil {
+		t.Fatal("expected malformed input error")
+	}
+}
+
+func TestLocalRunner_Serve(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	failing := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		return nil, errors.New("cannot process item")
+	}
+	server := httptest.NewServer(runtime.NewLocalRunner(harness.Context(), failing, runtime.WithMetrics("")).Mux())
+	defer server.Close()
+
+	post := func(payload []byte) (int, *localResponse) {
+		response, err := http.Post(server.URL, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		res := &localResponse{}
+		if err = json.NewDecoder(response.Body).Decode(res); err != nil {
+			t.Fatal(err)
+		}
+		return response.StatusCode, res
+	}
+
+	status, res := post(newSQSPayload(t))
+	if status != http.StatusOK || res.Error != "" {
+		t.Fatalf("expected partial batch response, found %d %+v", status, res)
+	}
+	expected := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{{ItemIdentifier: "A1"}}}
+	if diff := cvxtest.DiffJSON(expected, res.Response); diff != "" {
+		t.Fatalf("response mismatch (-expected +actual):\n%s", diff)
+	}
+
+	if status, res = post([]byte(`{"id":"1"}`)); status != http.StatusUnprocessableEntity || res.Error == "" {
+		t.Fatalf("expected unprocessable event, found %d %+v", status, res)
+	}
+
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected method not allowed, found %d", response.StatusCode)
+	}
+}
+
+func TestLocalRunner_DryRun(t *testing.T) {
+
+	t.Setenv("CVX_LOCAL_DRY_RUN", "true")
+	harness := cvxtest.NewHarness(nil)
+	output := bytes.NewBuffer(nil)
+	runner := runtime.NewLocalRunner(harness.Context(), createCounter, runtime.WithMetrics(""))
+	if err := runner.Read(bytes.NewReader(newSNSPayload(t, 1)), output); err != nil {
+		t.Fatal(err)
+	}
+
+	res := &localResponse{}
+	if err := json.Unmarshal(output.Bytes(), res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != "" || !res.Plan.DryRun || len(res.Plan.Transactions) != 1 {
+		t.Fatalf("expected one planned transaction, found %+v", res)
+	}
+	operations := make([]string, 0)
+	for _, item := range res.Plan.Transactions[0] {
+		operations = append(operations, item.Operation+" "+item.Table)
+	}
+	expected := []string{"Put dyn-cvxtest-cvxtest-statestore"}
+	if diff := cvxtest.DiffJSON(expected, operations); diff != "" {
+		t.Fatalf("plan mismatch (-expected +actual):\n%s", diff)
+	}
+	if counters, err := harness.Entities("Counter"); err != nil || len(counters) != 0 {
+		t.Fatalf("expected no stored counters on dry run, found %d: %v", len(counters), err)
+	}
+}
+
+func TestLocalRunner_EntityChange(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	counter, _ := newStreamFixture(t, harness)
+	changes := 0
+	onChange := func(ctx context.Context, oldEntity entity.Entity, newEntity entity.Entity) (result.Result, error) {
+		changes++
+		return nil, nil
+	}
+	runner := runtime.NewLocalEntityChangeRunner(harness.Context(), onChange, runtime.WithMetrics(""))
+
+	payload, err := json.Marshal(&events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{newStreamRecord(t, 1, nil, counter)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := bytes.NewBuffer(payload)
+	input.Write(newSNSPayload(t, 1))
+	output := bytes.NewBuffer(nil)
+	if err = runner.Read(input, output); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder := json.NewDecoder(output)
+	stream, sns := &localResponse{}, &localResponse{}
+	if err = decoder.Decode(stream); err != nil {
+		t.Fatal(err)
+	}
+	if err = decoder.Decode(sns); err != nil {
+		t.Fatal(err)
+	}
+	if stream.Error != "" || changes != 1 {
+		t.Fatalf("expected one entity change, found %d: %+v", changes, stream)
+	}
+	if sns.Error != "handler execution mode not found" {
+		t.Fatalf("expected non stream events to be rejected, found %+v", sns)
+	}
+}
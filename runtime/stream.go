@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	cvxcontext "github.com/cevixe/sdk/context"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/handler"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/pkg/errors"
+)
+
+func WrapEntityChangeHandler(hdl handler.EntityChangeHandler, opts ...Option) interface{} {
+	o := newOptions(opts...)
+
+	return func(ctx context.Context, input events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+
+		return processStreamBatch(ctx, input.Records, func(ctx context.Context, record events.DynamoDBEventRecord) error {
+			return processEntityChange(ctx, o, hdl, record)
+		}), nil
+	}
+}
+
+func createStreamMessageHandler(o *options, hdl handler.Handler) interface{} {
+
+	return func(ctx context.Context, input events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+
+		return processStreamBatch(ctx, input.Records, func(ctx context.Context, record events.DynamoDBEventRecord) error {
+			return processStreamRecord(ctx, o, hdl, record)
+		}), nil
+	}
+}
+
+func processStreamBatch(
+	ctx context.Context,
+	records []events.DynamoDBEventRecord,
+	process func(ctx context.Context, record events.DynamoDBEventRecord) error,
+) events.DynamoDBEventResponse {
+
+	response := events.DynamoDBEventResponse{
+		BatchItemFailures: make([]events.DynamoDBBatchItemFailure, 0),
+	}
+	for _, record := range records {
+		if record.EventName == "REMOVE" {
+			continue
+		}
+		if err := process(ctx, record); err != nil {
+			// stream records are checkpointed, following records are retried as well
+			response.BatchItemFailures = append(response.BatchItemFailures,
+				events.DynamoDBBatchItemFailure{
+					ItemIdentifier: record.Change.SequenceNumber,
+				})
+			break
+		}
+	}
+	return response
+}
+
+func processStreamRecord(ctx context.Context, o *options, hdl handler.Handler, record events.DynamoDBEventRecord) error {
+
+	newEntity, err := entity.FromStream(record)
+	if err != nil {
+		cvxcontext.GetLogger(ctx).Error("cannot read stream entity", "eventId", record.EventID, "error", err)
+		return errors.Wrap(err, "cannot read stream entity")
+	}
+	if entity.IsSnapshot(newEntity) {
+		return nil
+	}
+
+	msg, err := newEntity.LastEvent()
+	if err != nil {
+		return errors.Wrap(err, "cannot read stream entity last event")
+	}
+
+	return processMessage(ctx, o, hdl, msg)
+}
+
+func processEntityChange(ctx context.Context, o *options, hdl handler.EntityChangeHandler, record events.DynamoDBEventRecord) error {
+
+	oldEntity, newEntity, err := entity.FromStreamChange(record)
+	if err != nil {
+		cvxcontext.GetLogger(ctx).Error("cannot read stream entity change", "eventId", record.EventID, "error", err)
+		return errors.Wrap(err, "cannot read stream entity change")
+	}
+	if entity.IsSnapshot(newEntity) {
+		return nil
+	}
+
+	msg, err := newEntity.LastEvent()
+	if err != nil {
+		return errors.Wrap(err, "cannot read stream entity last event")
+	}
+
+	// the change handler is adapted to the last event so middlewares apply
+	chained := handler.Chain(func(ctx context.Context, msg message.Message) (result.Result, error) {
+		return hdl(ctx, oldEntity, newEntity)
+	}, o.middlewares...)
+	return processMessage(ctx, o, chained, msg)
+}
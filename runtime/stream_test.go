@@ -0,0 +1,214 @@
+package runtime_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cevixe/sdk/cvxtest"
+	"github.com/cevixe/sdk/entity"
+	"github.com/cevixe/sdk/handler"
+	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/result"
+	"github.com/cevixe/sdk/runtime"
+)
+
+func toStreamImage(t *testing.T, value entity.Entity) map[string]events.DynamoDBAttributeValue {
+	item, err := entity.ToDynamodb_Map(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	image := make(map[string]events.DynamoDBAttributeValue)
+	for name, attribute := range item {
+		image[name] = toStreamValue(t, attribute)
+	}
+	return image
+}
+
+func toStreamValue(t *testing.T, value types.AttributeValue) events.DynamoDBAttributeValue {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return events.NewStringAttribute(v.Value)
+	case *types.AttributeValueMemberN:
+		return events.NewNumberAttribute(v.Value)
+	case *types.AttributeValueMemberBOOL:
+		return events.NewBooleanAttribute(v.Value)
+	case *types.AttributeValueMemberNULL:
+		return events.NewNullAttribute()
+	case *types.AttributeValueMemberM:
+		image := make(map[string]events.DynamoDBAttributeValue)
+		for name, attribute := range v.Value {
+			image[name] = toStreamValue(t, attribute)
+		}
+		return events.NewMapAttribute(image)
+	case *types.AttributeValueMemberL:
+		list := make([]events.DynamoDBAttributeValue, 0, len(v.Value))
+		for _, attribute := range v.Value {
+			list = append(list, toStreamValue(t, attribute))
+		}
+		return events.NewListAttribute(list)
+	default:
+		t.Fatalf("unsupported attribute value %T", value)
+		return events.DynamoDBAttributeValue{}
+	}
+}
+
+func newStreamRecord(t *testing.T, sequence int, oldImage entity.Entity, newImage entity.Entity) events.DynamoDBEventRecord {
+	record := events.DynamoDBEventRecord{
+		EventID:   strconv.Itoa(sequence),
+		EventName: "MODIFY",
+		Change: events.DynamoDBStreamRecord{
+			SequenceNumber: strconv.Itoa(sequence),
+			NewImage:       toStreamImage(t, newImage),
+			StreamViewType: "NEW_AND_OLD_IMAGES",
+		},
+	}
+	if oldImage != nil {
+		record.Change.OldImage = toStreamImage(t, oldImage)
+	} else {
+		record.EventName = "INSERT"
+	}
+	return record
+}
+
+func newStreamFixture(t *testing.T, harness *cvxtest.Harness) (entity.Entity, entity.Entity) {
+	counter := harness.NewEntity(&Counter{Value: 1})
+	event, err := counter.LastEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := entity.NewSnapshot(&entity.SnapshotProps{
+		ID:        counter.ID(),
+		Typename:  counter.Type(),
+		Version:   counter.Version(),
+		State:     &Counter{Value: 1},
+		CreatedAt: counter.CreatedAt(),
+		CreatedBy: counter.CreatedBy(),
+		LastEvent: event,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return counter, snapshot
+}
+
+func TestStreamHandler_SkipsSnapshots(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	counter, snapshot := newStreamFixture(t, harness)
+
+	handled := make([]string, 0)
+	hdl := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		handled = append(handled, msg.Type())
+		return nil, nil
+	}
+	t.Setenv("CVX_HANDLER_MODE", "stream")
+	lmb := runtime.WrapHandler(hdl, runtime.WithMetrics("")).(func(context.Context, events.DynamoDBEvent) (events.DynamoDBEventResponse, error))
+
+	response, err := lmb(harness.Context(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		newStreamRecord(t, 1, nil, counter),
+		newStreamRecord(t, 2, nil, snapshot),
+		newStreamRecord(t, 3, snapshot, snapshot),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.BatchItemFailures) != 0 {
+		t.Fatalf("unexpected batch item failures %v", response.BatchItemFailures)
+	}
+	if diff := cvxtest.DiffJSON([]string{"counter.created.v1"}, handled); diff != "" {
+		t.Fatalf("handled events mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestEntityChangeHandler_SkipsSnapshots(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	counter, snapshot := newStreamFixture(t, harness)
+
+	handled := make([]string, 0)
+	hdl := func(ctx context.Context, oldEntity entity.Entity, newEntity entity.Entity) (result.Result, error) {
+		handled = append(handled, newEntity.ID())
+		return nil, nil
+	}
+	lmb := runtime.WrapEntityChangeHandler(hdl, runtime.WithMetrics("")).(func(context.Context, events.DynamoDBEvent) (events.DynamoDBEventResponse, error))
+
+	response, err := lmb(harness.Context(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		newStreamRecord(t, 1, nil, snapshot),
+		newStreamRecord(t, 2, nil, counter),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.BatchItemFailures) != 0 {
+		t.Fatalf("unexpected batch item failures %v", response.BatchItemFailures)
+	}
+	if diff := cvxtest.DiffJSON([]string{counter.ID()}, handled); diff != "" {
+		t.Fatalf("handled entities mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestEntityChangeHandler_AppliesMiddlewares(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	counter, _ := newStreamFixture(t, harness)
+
+	traced := make([]string, 0)
+	tracing := func(next handler.Handler) handler.Handler {
+		return func(ctx context.Context, msg message.Message) (result.Result, error) {
+			traced = append(traced, msg.Type())
+			return next(ctx, msg)
+		}
+	}
+	hdl := func(ctx context.Context, oldEntity entity.Entity, newEntity entity.Entity) (result.Result, error) {
+		panic("boom")
+	}
+	lmb := runtime.WrapEntityChangeHandler(hdl,
+		runtime.WithMetrics(""),
+		runtime.WithMiddleware(tracing, handler.Recovery()),
+	).(func(context.Context, events.DynamoDBEvent) (events.DynamoDBEventResponse, error))
+
+	response, err := lmb(harness.Context(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		newStreamRecord(t, 1, nil, counter),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON([]events.DynamoDBBatchItemFailure{{ItemIdentifier: "1"}}, response.BatchItemFailures); diff != "" {
+		t.Fatalf("batch item failures mismatch (-expected +actual):\n%s", diff)
+	}
+	if diff := cvxtest.DiffJSON([]string{"counter.created.v1"}, traced); diff != "" {
+		t.Fatalf("middleware calls mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestStreamHandler_PublishesLastEventVersion(t *testing.T) {
+
+	harness := cvxtest.NewHarness(nil)
+	ctx := harness.ExecutionContext(cvxtest.NewCommand(&cvxtest.MessageProps{Type: "increment.v1"}))
+	counter := harness.NewEntity(&Counter{Value: 1})
+	for value := 2; value < 7; value++ {
+		counter = counter.Mutate(ctx, &Counter{Value: value}).Execute()
+	}
+	previous := counter
+	counter = counter.Mutate(ctx, &Counter{Value: 7}).SetEvent("incremented", 2, nil).Execute()
+
+	handled := make([]string, 0)
+	hdl := func(ctx context.Context, msg message.Message) (result.Result, error) {
+		handled = append(handled, msg.Type())
+		return nil, nil
+	}
+	t.Setenv("CVX_HANDLER_MODE", "stream")
+	lmb := runtime.WrapHandler(hdl, runtime.WithMetrics("")).(func(context.Context, events.DynamoDBEvent) (events.DynamoDBEventResponse, error))
+
+	if _, err := lmb(harness.Context(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		newStreamRecord(t, 1, previous, counter),
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cvxtest.DiffJSON([]string{"counter.incremented.v2"}, handled); diff != "" {
+		t.Fatalf("handled events mismatch (-expected +actual):\n%s", diff)
+	}
+}
@@ -9,27 +9,43 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	cvxcontext "github.com/cevixe/sdk/context"
 	"github.com/cevixe/sdk/handler"
+	"github.com/cevixe/sdk/idempotency"
 	"github.com/cevixe/sdk/message"
+	"github.com/cevixe/sdk/metrics"
 	"github.com/cevixe/sdk/result"
 	"github.com/pkg/errors"
 )
 
-func WrapHandler(hdl handler.Handler) interface{} {
-	mode := os.Getenv("CVX_HANDLER_MODE")
+func WrapHandler(hdl handler.Handler, opts ...Option) interface{} {
+	o := newOptions(opts...)
+	lmb := wrapHandler(o, handler.Chain(hdl, o.middlewares...), os.Getenv("CVX_HANDLER_MODE"))
+	if lmb == nil {
+		log.Fatalln("handler execution mode not found")
+	}
+	return lmb
+}
+
+func wrapHandler(o *options, hdl handler.Handler, mode string) interface{} {
 	switch mode {
 	case "advanced":
-		return createSQSMessageHandler(hdl)
+		return createSQSMessageHandler(o, hdl)
 	case "standard":
-		return createSQSMessageHandler(hdl)
+		return createSQSMessageHandler(o, hdl)
 	case "basic":
-		return createSNSMessageHandler(hdl)
+		return createSNSMessageHandler(o, hdl)
+	case "stream":
+		return createStreamMessageHandler(o, hdl)
 	default:
-		log.Fatalln("handler execution mode not found")
 		return nil
 	}
 }
 
-func createSNSMessageHandler(hdl handler.Handler) interface{} {
+func Execute(ctx context.Context, hdl handler.Handler, msg message.Message, opts ...Option) error {
+	o := newOptions(opts...)
+	return processMessage(ctx, o, handler.Chain(hdl, o.middlewares...), msg)
+}
+
+func createSNSMessageHandler(o *options, hdl handler.Handler) interface{} {
 
 	return func(ctx context.Context, input events.SNSEvent) error {
 
@@ -43,96 +59,98 @@ func createSNSMessageHandler(hdl handler.Handler) interface{} {
 			return errors.Wrap(err, "cannot read sns message")
 		}
 
-		enrichedContext := loadExecutionContext(ctx, msg)
-		res, err := hdl(enrichedContext, msg)
-		if err != nil {
-			fmt.Printf("CVX Transaction: %s Event: %s/%s Error: %v\n",
-				msg.Transaction(), msg.Source(), msg.ID(), errors.Cause(err))
-			return errors.Wrap(err, "unsuccessful execution of message handler")
-		}
+		return processMessage(ctx, o, hdl, msg)
+	}
+}
 
-		if res == nil {
-			fmt.Printf("CVX Transaction: %s Event: %s/%s Result: <nil>\n",
-				msg.Transaction(), msg.Source(), msg.ID())
-			return nil
-		}
+func createSQSMessageHandler(o *options, hdl handler.Handler) interface{} {
 
-		if len(res.GetCommands()) == 0 &&
-			len(res.GetEntities()) == 0 {
-			fmt.Printf("CVX Transaction: %s Event: %s/%s Result: <empty>\n",
-				msg.Transaction(), msg.Source(), msg.ID())
-			return nil
-		}
+	return func(ctx context.Context, input events.SQSEvent) (events.SQSEventResponse, error) {
 
-		err = result.Write(enrichedContext, res)
-		if err != nil {
-			fmt.Printf("CVX Transaction: %s Event: %s/%s Error: %v\n",
-				msg.Transaction(), msg.Source(), msg.ID(), errors.Cause(err))
-			return errors.Wrap(err, "unexpected execution error of message handler")
-		} else {
-			fmt.Printf("CVX Transaction: %s Event: %s/%s Result: %dE/%dC\n",
-				msg.Transaction(), msg.Source(), msg.ID(),
-				len(res.GetEntities()), len(res.GetCommands()))
-			return nil
-		}
+		return processSQSBatch(ctx, o, hdl, input.Records), nil
 	}
 }
 
-func createSQSMessageHandler(hdl handler.Handler) interface{} {
+func processMessage(ctx context.Context, o *options, hdl handler.Handler, msg message.Message) error {
 
-	return func(ctx context.Context, input events.SQSEvent) error {
+	return execute(ctx, o, msg, func(ctx context.Context) (result.Result, error) {
+		return hdl(ctx, msg)
+	})
+}
 
-		if len(input.Records) != 1 {
-			return errors.New("unsupported event stream configuration")
-		}
+func executeAttempt(ctx context.Context, o *options, msg message.Message, run func(ctx context.Context) (result.Result, error)) error {
 
-		record := input.Records[0]
-		msg, err := message.FromSQS(record)
-		if err != nil {
-			return errors.Wrap(err, "cannot read sqs message")
-		}
+	enrichedContext := loadExecutionContext(ctx, o, msg)
+	log := cvxcontext.GetLogger(enrichedContext)
 
-		enrichedContext := loadExecutionContext(ctx, msg)
-		res, err := hdl(enrichedContext, msg)
-		if err != nil {
-			fmt.Printf("CVX Transaction: %s Event: %s/%s Error: %v\n",
-				msg.Transaction(), msg.Source(), msg.ID(), errors.Cause(err))
-			return errors.Wrap(err, "unsuccessful execution of message handler")
-		}
+	processed, err := idempotency.IsProcessed(enrichedContext)
+	if err != nil {
+		log.Error("cannot validate message deduplication", "error", errors.Cause(err))
+		return errors.Wrap(err, "cannot validate message deduplication")
+	}
+	if processed {
+		log.Info("message processed", "result", "duplicate")
+		metrics.SetDimension(enrichedContext, "Outcome", "duplicate")
+		return nil
+	}
 
-		if res == nil {
-			fmt.Printf("CVX Transaction: %s Event: %s/%s Result: <nil>\n",
-				msg.Transaction(), msg.Source(), msg.ID())
-			return nil
-		}
+	res, err := run(enrichedContext)
+	if err != nil {
+		log.Error("unsuccessful execution of message handler", "error", errors.Cause(err))
+		return errors.Wrap(err, "unsuccessful execution of message handler")
+	}
 
-		if len(res.GetCommands()) == 0 &&
-			len(res.GetEntities()) == 0 {
-			fmt.Printf("CVX Transaction: %s Event: %s/%s Result: <empty>\n",
-				msg.Transaction(), msg.Source(), msg.ID())
-			return nil
-		}
+	if res == nil {
+		log.Info("message processed", "result", "nil")
+		metrics.SetDimension(enrichedContext, "Outcome", "nil")
+		return markProcessed(enrichedContext)
+	}
 
-		err = result.Write(enrichedContext, res)
-		if err != nil {
-			fmt.Printf("CVX Transaction: %s Event: %s/%s Error: %v\n",
-				msg.Transaction(), msg.Source(), msg.ID(), errors.Cause(err))
-			return errors.Wrap(err, "unexpected execution error of message handler")
-		} else {
-			fmt.Printf("CVX Transaction: %s Event: %s/%s Result: %dE/%dC\n",
-				msg.Transaction(), msg.Source(), msg.ID(),
-				len(res.GetEntities()), len(res.GetCommands()))
-			return nil
-		}
+	if len(res.GetCommands()) == 0 &&
+		len(res.GetEntities()) == 0 &&
+		len(result.Events(res)) == 0 &&
+		len(result.Snapshots(res)) == 0 {
+		log.Info("message processed", "result", "empty")
+		metrics.SetDimension(enrichedContext, "Outcome", "empty")
+		return markProcessed(enrichedContext)
+	}
+
+	err = result.Write(idempotency.WithRecord(enrichedContext), res)
+	if err != nil {
+		log.Error("unexpected execution error of message handler", "error", errors.Cause(err))
+		return errors.Wrap(err, "unexpected execution error of message handler")
+	} else {
+		log.Info("message processed", "result", "written",
+			"entities", len(res.GetEntities()), "commands", len(res.GetCommands()), "events", len(result.Events(res)))
+		metrics.SetDimension(enrichedContext, "Outcome", "written")
+		metrics.Put(enrichedContext, "Entities", float64(len(res.GetEntities())), metrics.Unit_Count)
+		metrics.Put(enrichedContext, "Commands", float64(len(res.GetCommands())), metrics.Unit_Count)
+		metrics.Put(enrichedContext, "Events", float64(len(result.Events(res))), metrics.Unit_Count)
+		return nil
+	}
+}
+
+func markProcessed(ctx context.Context) error {
+
+	if !idempotency.IsEnabled(ctx) {
+		return nil
+	}
+
+	if err := result.Write(idempotency.WithRecord(ctx), result.NewResult()); err != nil {
+		cvxcontext.GetLogger(ctx).Error("cannot write message deduplication record", "error", errors.Cause(err))
+		return errors.Wrap(err, "cannot write message deduplication record")
 	}
+	return nil
 }
 
-func loadExecutionContext(ctx context.Context, msg message.Message) context.Context {
+func loadExecutionContext(ctx context.Context, o *options, msg message.Message) context.Context {
 
 	return context.WithValue(ctx, cvxcontext.CevixeExecutionContextKey,
 		&cvxcontext.ExecutionContext{
-			Author:      msg.Author(),
-			Trigger:     fmt.Sprintf("%s/%s", msg.Source(), msg.ID()),
-			Transaction: msg.Transaction(),
+			Author:           msg.Author(),
+			Trigger:          fmt.Sprintf("%s/%s", msg.Source(), msg.ID()),
+			Transaction:      msg.Transaction(),
+			MessageType:      msg.Type(),
+			DeduplicationTTL: o.deduplicationTTL,
 		})
 }
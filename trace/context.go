@@ -0,0 +1,92 @@
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&0x01 == 0x01
+}
+
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+func ParseTraceParent(traceparent string, tracestate string) (SpanContext, error) {
+
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, errors.New("invalid traceparent format")
+	}
+	if len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, errors.New("unsupported traceparent version")
+	}
+
+	sc := SpanContext{TraceState: tracestate}
+	if err := decodeHex(parts[1], sc.TraceID[:]); err != nil {
+		return SpanContext{}, errors.Wrap(err, "invalid traceparent trace id")
+	}
+	if err := decodeHex(parts[2], sc.SpanID[:]); err != nil {
+		return SpanContext{}, errors.Wrap(err, "invalid traceparent span id")
+	}
+	flags := make([]byte, 1)
+	if err := decodeHex(parts[3], flags); err != nil {
+		return SpanContext{}, errors.Wrap(err, "invalid traceparent flags")
+	}
+	sc.Flags = flags[0]
+
+	if !sc.IsValid() {
+		return SpanContext{}, errors.New("invalid traceparent identifiers")
+	}
+	return sc, nil
+}
+
+func decodeHex(value string, target []byte) error {
+	if len(value) != hex.EncodedLen(len(target)) || strings.ToLower(value) != value {
+		return errors.New("invalid hex length or case")
+	}
+	_, err := hex.Decode(target, []byte(value))
+	return err
+}
+
+func newTraceID() TraceID {
+	id := TraceID{}
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	id := SpanID{}
+	_, _ = rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,65 @@
+package trace
+
+import (
+	"sync"
+)
+
+type Exporter interface {
+	ExportSpan(span *SpanData)
+}
+
+var (
+	exporterMutex sync.RWMutex
+	exporter      Exporter = noopExporter{}
+)
+
+func SetExporter(exp Exporter) {
+	exporterMutex.Lock()
+	defer exporterMutex.Unlock()
+	if exp == nil {
+		exporter = noopExporter{}
+		return
+	}
+	exporter = exp
+}
+
+func getExporter() Exporter {
+	exporterMutex.RLock()
+	defer exporterMutex.RUnlock()
+	return exporter
+}
+
+type noopExporter struct{}
+
+func (noopExporter) ExportSpan(*SpanData) {}
+
+type Recorder struct {
+	mutex sync.Mutex
+	spans []*SpanData
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		spans: make([]*SpanData, 0),
+	}
+}
+
+func (r *Recorder) ExportSpan(span *SpanData) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func (r *Recorder) Spans() []*SpanData {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	spans := make([]*SpanData, len(r.spans))
+	copy(spans, r.spans)
+	return spans
+}
+
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.spans = make([]*SpanData, 0)
+}
@@ -0,0 +1,129 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type spanContextKey string
+
+const currentSpanKey spanContextKey = "cvxspan"
+
+type SpanData struct {
+	Name         string
+	SpanContext  SpanContext
+	ParentSpanID SpanID
+	Remote       bool
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]interface{}
+	Error        string
+}
+
+type Span interface {
+	SpanContext() SpanContext
+	SetAttributes(args ...interface{})
+	RecordError(err error)
+	End()
+}
+
+func Start(ctx context.Context, name string, args ...interface{}) (context.Context, Span) {
+
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{
+		TraceID: parent.TraceID,
+		SpanID:  newSpanID(),
+		Flags:   parent.Flags,
+	}
+	if parent.IsValid() {
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Flags = 0x01
+	}
+
+	span := &spanImpl{
+		data: SpanData{
+			Name:         name,
+			SpanContext:  sc,
+			ParentSpanID: parent.SpanID,
+			Remote:       isRemote(ctx),
+			Start:        time.Now(),
+			Attributes:   make(map[string]interface{}),
+		},
+	}
+	span.SetAttributes(args...)
+
+	return context.WithValue(ctx, currentSpanKey, &spanRef{context: sc}), span
+}
+
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ref, ok := ctx.Value(currentSpanKey).(*spanRef); ok && ref != nil {
+		return ref.context
+	}
+	return SpanContext{}
+}
+
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, currentSpanKey, &spanRef{context: sc, remote: true})
+}
+
+func isRemote(ctx context.Context) bool {
+	if ref, ok := ctx.Value(currentSpanKey).(*spanRef); ok && ref != nil {
+		return ref.remote
+	}
+	return false
+}
+
+type spanRef struct {
+	context SpanContext
+	remote  bool
+}
+
+type spanImpl struct {
+	mutex sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *spanImpl) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+func (s *spanImpl) SetAttributes(args ...interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for idx := 0; idx+1 < len(args); idx += 2 {
+		s.data.Attributes[fmt.Sprint(args[idx])] = args[idx+1]
+	}
+}
+
+func (s *spanImpl) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.data.Error = err.Error()
+}
+
+func (s *spanImpl) End() {
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mutex.Unlock()
+
+	if data.SpanContext.IsSampled() {
+		getExporter().ExportSpan(&data)
+	}
+}